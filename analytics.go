@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+func analyticsCommand(args []string) {
+	analyticsCmd := newFlagSet("analytics")
+	top := analyticsCmd.Int("top", 10, "number of frequent and low scoring questions to list")
+	threshold := analyticsCmd.Float64("threshold", 0.5, "top chunk similarity below which a query counts as low scoring")
+	analyticsCmd.Parse(args)
+
+	if queryLogPath == "" {
+		fmt.Fprintln(os.Stderr, "usage: vdb analytics --query-log queries.jsonl, or set VDB_QUERY_LOG")
+		os.Exit(2)
+	}
+	file, err := os.Open(queryLogPath)
+	if err != nil {
+		fail("cannot open query log", err)
+	}
+	records, err := vdb.ReadQueryLog(file)
+	file.Close()
+	if err != nil {
+		fail("cannot read query log", err)
+	}
+	report := vdb.AnalyzeQueries(records, *top, float32(*threshold))
+
+	if jsonOutput {
+		printJSON(report)
+		return
+	}
+	fmt.Printf("queries: %d, errors: %d, cached: %d\n", report.Queries, report.Errors, report.Cached)
+	fmt.Printf("average latency: retrieval %.0fms, generation %.0fms, total %.0fms\n",
+		report.Latencies.Retrieval, report.Latencies.Generation, report.Latencies.Total)
+
+	fmt.Println("\nmost frequent questions:")
+	for _, q := range report.Frequent {
+		fmt.Printf("%6d  %s\n", q.Count, preview(q.Question, 100))
+	}
+
+	fmt.Printf("\nlow scoring queries (top score below %.2f), consider adding documents for these:\n", *threshold)
+	for _, r := range report.LowScore {
+		fmt.Printf("%6.3f  %s\n", r.TopScore, preview(r.Question, 100))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// an answered question in a batch, by its position in the batch
+type batchAnswer struct {
+	index  int
+	result map[string]any
+}
+
+// answers the questions using up to concurrency at a time, writing the
+// answers as JSON lines to output, or standard output if it is empty, in
+// the order of the questions
+func batchCall(ctx context.Context, client *vdb.Client, questions []string, sources []string, output string, concurrency int) {
+	if concurrency < 1 {
+		fail("cannot answer questions", fmt.Errorf("invalid --concurrency %d", concurrency))
+	}
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			fail("cannot create answers file", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	indexes := make(chan int)
+	answers := make(chan batchAnswer)
+	var workers sync.WaitGroup
+	for i := 0; i < min(concurrency, len(questions)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range indexes {
+				answers <- batchAnswer{index, answerResult(ctx, client, questions[index], sources)}
+			}
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for i := range questions {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(answers)
+	}()
+
+	// answers are written as soon as all the questions before them are
+	encoder := json.NewEncoder(w)
+	pending := map[int]map[string]any{}
+	next, failed := 0, 0
+	for answer := range answers {
+		pending[answer.index] = answer.result
+		for ; pending[next] != nil; next++ {
+			if _, ok := pending[next]["error"]; ok {
+				failed++
+			}
+			if err := encoder.Encode(pending[next]); err != nil {
+				fail("cannot write answer", err)
+			}
+			delete(pending, next)
+		}
+		log.Printf("answered %d of %d questions\n", next, len(questions))
+	}
+
+	if err := ctx.Err(); err != nil {
+		fail("cannot answer questions", err)
+	}
+	if failed > 0 {
+		log.Printf("%d of %d questions could not be answered\n", failed, len(questions))
+		os.Exit(1)
+	}
+}
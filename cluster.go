@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// a topic cluster, as reported in JSON output
+type clusterReport struct {
+	Label           string        `json:"label,omitempty"`
+	Size            int           `json:"size"`
+	Sources         []string      `json:"sources"`
+	Representatives []chunkSource `json:"representatives"`
+}
+
+func clusterCommand(ctx context.Context, args []string) {
+	clusterCmd := newFlagSet("cluster")
+	k := clusterCmd.Int("k", 8, "number of clusters")
+	samples := clusterCmd.Int("samples", 3, "number of representative chunks to list and label each cluster with")
+	seed := clusterCmd.Int64("seed", 1, "random seed for choosing the starting centroids")
+	noLabels := clusterCmd.Bool("no-labels", false, "do not ask the model to name the topic of each cluster")
+	sourceList := clusterCmd.String("sources", "", "comma-separated list of documents to cluster, all if empty")
+	clusterCmd.Parse(args)
+	if *samples < 0 {
+		fail("cannot cluster chunks", fmt.Errorf("invalid --samples %d", *samples))
+	}
+
+	client := openClient(true)
+	sources := parseSources(client.Store(), *sourceList)
+	if err := checkSources(client.Store(), sources); err != nil {
+		fail("cannot restrict sources", err)
+	}
+	chunks := client.Store().Chunks()
+	if len(sources) > 0 {
+		chunks = nil
+		for _, source := range sources {
+			chunks = append(chunks, client.Store().DocumentChunks(source)...)
+		}
+	}
+
+	clusters, err := vdb.KMeans(chunks, *k, *seed)
+	if err != nil {
+		fail("cannot cluster chunks", err)
+	}
+	if !*noLabels {
+		waitForOllama(ctx)
+		for i := range clusters {
+			log.Printf("labelling cluster %d of %d\n", i+1, len(clusters))
+			clusters[i].Label, err = client.LabelCluster(ctx, clusters[i], *samples)
+			if err != nil {
+				fail("cannot label cluster", err)
+			}
+		}
+	}
+
+	reports := make([]clusterReport, 0, len(clusters))
+	for _, c := range clusters {
+		reports = append(reports, clusterReport{
+			Label:           c.Label,
+			Size:            len(c.Chunks),
+			Sources:         clusterSources(c),
+			Representatives: chunkSources(c.Chunks[:min(*samples, len(c.Chunks))]),
+		})
+	}
+	if jsonOutput {
+		printJSON(reports)
+		return
+	}
+	for i, report := range reports {
+		label := report.Label
+		if label == "" {
+			label = fmt.Sprintf("cluster %d", i+1)
+		}
+		fmt.Printf("%s (%d chunks from %d documents)\n", label, report.Size, len(report.Sources))
+		for _, chunk := range clusters[i].Chunks[:len(report.Representatives)] {
+			fmt.Printf("    %-30s %s\n", chunk.Source, preview(chunk.Content, 80))
+		}
+		fmt.Println()
+	}
+}
+
+// the documents a cluster's chunks come from, in order of first appearance
+func clusterSources(c vdb.Cluster) []string {
+	seen := map[string]bool{}
+	var sources []string
+	for _, chunk := range c.Chunks {
+		if !seen[chunk.Source] {
+			seen[chunk.Source] = true
+			sources = append(sources, chunk.Source)
+		}
+	}
+	return sources
+}
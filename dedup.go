@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// a near-duplicate chunk, as reported in JSON output
+type duplicateReport struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source"`
+	OfID     string  `json:"of_id"`
+	OfSource string  `json:"of_source"`
+	Score    float32 `json:"score"`
+}
+
+func dedupCommand(args []string) {
+	dedupCmd := newFlagSet("dedup")
+	threshold := dedupCmd.Float64("threshold", vdb.DefaultDuplicateThreshold, "similarity at or above which chunks are near-duplicates")
+	prune := dedupCmd.Bool("prune", false, "remove the near-duplicate chunks, keeping the first copy, and save vdb.gob")
+	dedupCmd.Parse(args)
+
+	store := openClient(true).Store()
+	duplicates := store.FindDuplicates(float32(*threshold))
+	documents := store.DuplicateDocuments(duplicates)
+
+	pruned := 0
+	if *prune && len(duplicates) > 0 {
+		ids := make([]string, len(duplicates))
+		for i, d := range duplicates {
+			ids[i] = d.Chunk.ID
+		}
+		pruned = store.Remove(ids...)
+		if err := store.Save(); err != nil {
+			fail("cannot save store", err)
+		}
+	}
+
+	if jsonOutput {
+		reports := make([]duplicateReport, 0, len(duplicates))
+		for _, d := range duplicates {
+			reports = append(reports, duplicateReport{
+				ID:       d.Chunk.ID,
+				Source:   d.Chunk.Source,
+				OfID:     d.Of.ID,
+				OfSource: d.Of.Source,
+				Score:    d.Score,
+			})
+		}
+		if documents == nil {
+			documents = []vdb.DocumentDuplicates{}
+		}
+		printJSON(map[string]any{
+			"duplicates": reports,
+			"documents":  documents,
+			"pruned":     pruned,
+		})
+		return
+	}
+
+	for _, d := range duplicates {
+		fmt.Printf("%s %-30s duplicates %s %-30s %.4f\n    %s\n", d.Chunk.ID, d.Chunk.Source, d.Of.ID, d.Of.Source, d.Score, preview(d.Chunk.Content, 80))
+	}
+	if len(documents) > 0 {
+		fmt.Println()
+	}
+	for _, d := range documents {
+		note := ""
+		if d.Duplicates == d.Chunks {
+			note = " (entirely redundant)"
+		}
+		fmt.Printf("%-40s %d of %d chunks duplicated%s\n", d.Name, d.Duplicates, d.Chunks, note)
+	}
+
+	switch {
+	case len(duplicates) == 0:
+		log.Println("no near-duplicate chunks found")
+	case *prune:
+		log.Printf("removed %d near-duplicate chunks\n", pruned)
+	default:
+		log.Printf("found %d near-duplicate chunks, run with --prune to remove them\n", len(duplicates))
+	}
+}
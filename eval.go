@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// the retrieval metrics for one chunking and embedding model configuration
+type evalReport struct {
+	Chunker string           `json:"chunker"`
+	Model   string           `json:"model"`
+	Results []vdb.EvalResult `json:"results"`
+}
+
+func evalCommand(ctx context.Context, args []string) {
+	evalCmd := newFlagSet("eval")
+	kList := evalCmd.String("k", "1,3,5,10", "comma-separated numbers of retrieved chunks to measure")
+	modelList := evalCmd.String("models", "", "comma-separated embedding models to compare, by default the store's model")
+	chunkerList := evalCmd.String("chunkers", "stored", "comma-separated chunkers to compare: stored, paragraph or window:<size>:<overlap>")
+	docList := evalCmd.String("docs", "", "comma-separated documents to re-ingest for chunkers other than stored")
+	evalCmd.Parse(args)
+
+	if evalCmd.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: vdb eval [flags] cases.jsonl")
+		os.Exit(2)
+	}
+	file, err := os.Open(evalCmd.Arg(0))
+	if err != nil {
+		fail("cannot open evaluation cases", err)
+	}
+	cases, err := vdb.ReadEvalCases(file)
+	file.Close()
+	if err != nil {
+		fail("cannot read evaluation cases", err)
+	}
+
+	var ks []int
+	for _, item := range splitList(*kList) {
+		k, err := strconv.Atoi(item)
+		if err != nil || k <= 0 {
+			fail("cannot parse -k", fmt.Errorf("invalid k %q", item))
+		}
+		ks = append(ks, k)
+	}
+	docs := splitList(*docList)
+	loadConverters()
+	store := openClient(true).Store()
+	models := splitList(*modelList)
+	if len(models) == 0 {
+		models = []string{store.EmbeddingModel()}
+		if models[0] == "" {
+			models[0] = vdb.DefaultEmbeddingModel
+		}
+	}
+
+	waitForOllama(ctx)
+	var reports []evalReport
+	for _, chunkerName := range splitList(*chunkerList) {
+		for _, model := range models {
+			log.Printf("evaluating chunker %s with model %s\n", chunkerName, model)
+			embedder := vdb.NewOllamaEmbedder(model)
+			evalStore, err := buildEvalStore(ctx, store, chunkerName, embedder, docs)
+			if err != nil {
+				fail("cannot build store for "+chunkerName, err)
+			}
+			retriever := &vdb.Retriever{Store: evalStore, Embedder: embedder, Timeout: timeouts.Search}
+			results, err := vdb.Evaluate(ctx, retriever, cases, ks)
+			if err != nil {
+				fail("cannot evaluate", err)
+			}
+			reports = append(reports, evalReport{Chunker: chunkerName, Model: model, Results: results})
+		}
+	}
+
+	if jsonOutput {
+		printJSON(reports)
+		return
+	}
+	fmt.Printf("%-24s %-24s %4s %8s %8s %8s\n", "chunker", "model", "k", "recall", "mrr", "ndcg")
+	for _, report := range reports {
+		for _, r := range report.Results {
+			fmt.Printf("%-24s %-24s %4d %8.4f %8.4f %8.4f\n", report.Chunker, report.Model, r.K, r.Recall, r.MRR, r.NDCG)
+		}
+	}
+}
+
+// the store to evaluate a configuration against. The stored chunks are used
+// as they are if they were embedded with the same model, and re-embedded
+// into an in-memory store otherwise. Other chunkers re-ingest the documents
+// into an in-memory store
+func buildEvalStore(ctx context.Context, store *vdb.Store, chunkerName string, embedder *vdb.OllamaEmbedder, docs []string) (*vdb.Store, error) {
+	if chunkerName == "stored" {
+		storeModel := store.EmbeddingModel()
+		if storeModel == "" {
+			storeModel = vdb.DefaultEmbeddingModel
+		}
+		if embedder.Model == storeModel {
+			return store, nil
+		}
+		evalStore := vdb.NewStore("")
+		chunks := store.Chunks()
+		for i := range chunks {
+			chunks[i].Embedding = nil
+		}
+		_, err := vdb.NewPipeline(evalStore, embedder).WithTimeouts(timeouts).Store(ctx, chunks)
+		return evalStore, err
+	}
+
+	chunker, err := parseChunker(chunkerName)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("chunker %s needs the documents to re-ingest, given with --docs", chunkerName)
+	}
+	evalStore := vdb.NewStore("")
+	pipeline := vdb.NewPipeline(evalStore, embedder).WithChunker(chunker).WithTimeouts(timeouts)
+	_, err = pipeline.Run(ctx, docs...)
+	return evalStore, err
+}
+
+// parses a chunker name: paragraph for vdb.Split, or window:<size>:<overlap>
+// for a vdb.WindowChunker
+func parseChunker(name string) (vdb.Chunker, error) {
+	if name == "paragraph" {
+		return vdb.ChunkerFunc(vdb.Split), nil
+	}
+	parts := strings.Split(name, ":")
+	if len(parts) == 3 && parts[0] == "window" {
+		size, sizeErr := strconv.Atoi(parts[1])
+		overlap, overlapErr := strconv.Atoi(parts[2])
+		if sizeErr == nil && overlapErr == nil && size > overlap && overlap >= 0 {
+			return vdb.WindowChunker{Size: size, Overlap: overlap}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown chunker %q", name)
+}
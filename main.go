@@ -1,236 +1,535 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
-	"math"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
+	"syscall"
 
-	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/ollama"
-	"github.com/tmc/langchaingo/schema"
+	"github.com/sausheong/vdb/pkg/vdb"
 )
 
-var vdb []VectorDocument
+// the file the vector store is saved to
+const storePath = "vdb.gob"
 
-type VectorDocument struct {
-	Embedding []float32
-	Content   string
-}
+// the file answers to questions are cached in, see vdb.AnswerCache
+const cachePath = storePath + ".cache"
 
-func main() {
-	// start the Ollama server
-	go startOllamaServer()
+// the file external converters are configured in, see vdb.LoadConverters
+var convertersPath = "converters.json"
 
-	// add the given document into vdb.gob
-	if os.Args[1] == "add" {
-		log.Println("adding document:", os.Args[2])
-		content, _ := convert(os.Args[2])
-		addVectorDocuments(clean(content))
+// when set, subcommands print JSON to stdout instead of text
+var jsonOutput bool
+
+// per-stage timeouts for ingestion and answering
+var timeouts vdb.Timeouts
+
+// the file questions are logged to for vdb analytics, no logging if empty
+var queryLogPath = os.Getenv("VDB_QUERY_LOG")
+
+func main() {
+	addGlobalFlags(flag.CommandLine)
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: vdb [--json] add|call|ls|eval|reembed|analytics|dedup|cluster|project|sync|snapshot|tui [flags] [args]")
+		os.Exit(2)
 	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	// Ctrl-C cancels whatever is running, a second Ctrl-C exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// start the Ollama server, commands that need it wait for it to be ready
+	runOllamaServer()
 
+	switch command {
+	// add the given document into vdb.gob
+	case "add":
+		addCommand(ctx, args)
 	// loads vector documents from vdb.gob, gets text chunks
 	// related to the question, calls the LLM using the chunks
-	if os.Args[1] == "call" {
-		log.Println("calling model with document")
-		loadVdb()
-		chunks := getSimilarChunks(os.Args[2])
-		call("llama2", strings.Join(chunks, "\n"), os.Args[2])
+	case "call":
+		callCommand(ctx, args)
+	// lists the documents stored in vdb.gob
+	case "ls":
+		lsCommand(args)
+	// measures retrieval quality against expected results
+	case "eval":
+		evalCommand(ctx, args)
+	// re-embeds the stored chunks with another embedding model
+	case "reembed":
+		reembedCommand(ctx, args)
+	// summarizes the questions recorded in the query log
+	case "analytics":
+		analyticsCommand(args)
+	// finds and optionally removes near-duplicate chunks
+	case "dedup":
+		dedupCommand(args)
+	// groups the stored chunks into topics
+	case "cluster":
+		clusterCommand(ctx, args)
+	// projects the embeddings to 2D for visualization
+	case "project":
+		projectCommand(args)
+	// keeps the store up to date with directories and URLs
+	case "sync":
+		syncCommand(ctx, args)
+	// saves and restores named states of vdb.gob
+	case "snapshot":
+		snapshotCommand(args)
+	// interactive terminal UI for browsing, searching and chatting
+	case "tui":
+		runTUI(ctx, args)
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command:", command)
+		os.Exit(2)
 	}
 }
 
-// adds vector documents into the vdb.gob file
-func addVectorDocuments(content []string) {
-	file, err := os.OpenFile("vdb.gob", os.O_RDWR|os.O_CREATE, 0666)
-	if err != nil {
-		log.Println("cannot open gob file:", err)
+// creates the flag set for a subcommand, including the global flags
+// so they can be given either before or after the subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	addGlobalFlags(fs)
+	return fs
+}
+
+// registers the flags every subcommand accepts, defaulting to the values
+// already given before the subcommand
+func addGlobalFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&jsonOutput, "json", jsonOutput, "print machine-readable JSON output")
+	fs.StringVar(&convertersPath, "converters", convertersPath, "JSON file configuring external converters by file extension")
+	fs.StringVar(&queryLogPath, "query-log", queryLogPath, "append questions asked to this JSON lines file, defaults to $VDB_QUERY_LOG")
+	fs.DurationVar(&ollamaStartTimeout, "ollama-timeout", ollamaStartTimeout, "maximum time to wait for the Ollama server to be ready")
+	fs.DurationVar(&timeouts.Convert, "convert-timeout", timeouts.Convert, "maximum time to convert a document, 0 for no limit")
+	fs.DurationVar(&timeouts.Embed, "embed-timeout", timeouts.Embed, "maximum time to embed a document, 0 for no limit")
+	fs.DurationVar(&timeouts.Search, "search-timeout", timeouts.Search, "maximum time to search for chunks, 0 for no limit")
+	fs.DurationVar(&timeouts.Generate, "generate-timeout", timeouts.Generate, "maximum time to generate an answer, 0 for no limit")
+}
+
+// prints v to stdout as indented JSON
+func printJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Println("cannot encode JSON:", err)
 	}
-	defer file.Close()
+}
 
-	embeddings, err := getEmbeddings(content)
-	if err != nil {
-		log.Println("cannot get embeddings", err)
+// the kinds of failure reported in JSON output, with a hint on how to fix
+// each of them
+var failures = []struct {
+	err  error
+	kind string
+	hint string
+}{
+	{vdb.ErrStoreNotFound, "store_not_found", "add a document first with vdb add"},
+	{vdb.ErrDimensionMismatch, "dimension_mismatch", "the store was built with a different embedding model"},
+	{vdb.ErrEmbeddingFailed, "embedding_failed", "check that Ollama is running and the embedding model has been pulled"},
+	{vdb.ErrUnsupportedFormat, "unsupported_format", "configure a converter for the format in converters.json"},
+	{vdb.ErrSnapshotNotFound, "snapshot_not_found", "see vdb snapshot list for the saved snapshots"},
+	{vdb.ErrOllamaUnavailable, "ollama_unavailable", "check that Ollama can start, or is running at OLLAMA_HOST"},
+	{context.DeadlineExceeded, "timeout", "increase the stage timeout"},
+	{context.Canceled, "cancelled", ""},
+}
+
+// the kind of failure and a hint on how to fix it, if the error is one
+// of the known kinds
+func describeError(err error) (kind string, hint string) {
+	for _, f := range failures {
+		if errors.Is(err, f.err) {
+			return f.kind, f.hint
+		}
 	}
+	return "error", ""
+}
 
-	for i, c := range content {
-		doc := VectorDocument{
-			Embedding: embeddings[i],
-			Content:   c,
+// reports the error, with a hint on how to fix it, and exits
+func fail(msg string, err error) {
+	kind, hint := describeError(err)
+	if jsonOutput {
+		printJSON(map[string]string{
+			"error": fmt.Sprintf("%s: %v", msg, err),
+			"kind":  kind,
+		})
+	} else {
+		log.Printf("%s: %v\n", msg, err)
+		if hint != "" {
+			log.Println(hint)
 		}
-		vdb = append(vdb, doc)
 	}
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(vdb)
+	os.Exit(1)
+}
+
+// registers the external converters configured in the converters file.
+// It is only an error for the file not to exist if it was given explicitly
+func loadConverters() {
+	vdb.PluginLoader = loadPluginConverter
+	err := vdb.LoadConverters(convertersPath)
+	if errors.Is(err, fs.ErrNotExist) && convertersPath == "converters.json" {
+		return
+	}
 	if err != nil {
-		log.Println("cannot save vdb to file", err)
+		fail("cannot load converters", err)
 	}
 }
 
-// loads the vdb variable from vdb.gob
-func loadVdb() {
-	file, err := os.Open("vdb.gob")
-	if err != nil {
-		log.Println("Error opening file:", err)
-		return
+// opens vdb.gob and returns a client for it with the given options,
+// exiting if it cannot be read. If mustExist is set, it is also an error
+// for vdb.gob not to exist
+func openClient(mustExist bool, extra ...vdb.Option) *vdb.Client {
+	store := vdb.NewStore(storePath)
+	err := store.Load()
+	if err != nil && (mustExist || !errors.Is(err, vdb.ErrStoreNotFound)) {
+		fail("cannot open store", err)
 	}
-	defer file.Close()
+	log.Printf("loaded %d records into vdb\n", store.Len())
+	opts := []vdb.Option{vdb.WithTimeouts(timeouts)}
+	if queryLogPath != "" {
+		// the log is closed when the process exits
+		file, err := os.OpenFile(queryLogPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			fail("cannot open query log", err)
+		}
+		opts = append(opts, vdb.WithQueryLog(file))
+	}
+	return vdb.NewClient(store, append(opts, extra...)...)
+}
 
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(&vdb)
+func addCommand(ctx context.Context, args []string) {
+	addCmd := newFlagSet("add")
+	dryRun := addCmd.Bool("dry-run", false, "report what would be stored without changing vdb.gob")
+	probe := addCmd.Bool("probe", false, "with --dry-run, embed a single chunk to check the embedding model")
+	addCmd.Parse(args)
+
+	loadConverters()
+	client := openClient(false)
+	log.Println("adding document:", addCmd.Arg(0))
+	if !*dryRun || *probe {
+		waitForOllama(ctx)
+	}
+	if *dryRun {
+		content, err := client.Convert(ctx, addCmd.Arg(0))
+		if err != nil {
+			fail("cannot convert document", err)
+		}
+		dryRunAdd(ctx, client, vdb.Split(content), *probe)
+		return
+	}
+	ids, err := client.Ingest(ctx, addCmd.Arg(0))
 	if err != nil {
-		log.Println("Error decoding:", err)
+		fail("cannot add document", err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{
+			"source": filepath.Base(addCmd.Arg(0)),
+			"ids":    ids,
+		})
 		return
 	}
-	log.Printf("loaded %d records into vdb\n", len(vdb))
+	log.Printf("added %d chunks\n", len(ids))
 }
 
-// converts pdf into text using xpdfreader's pdftotext
-func convert(inputpdf string) (string, error) {
-	tempdir, err := os.MkdirTemp("", "vdb")
+func callCommand(ctx context.Context, args []string) {
+	callCmd := newFlagSet("call")
+	questionFile := callCmd.String("f", "", "read questions from this file, one per line")
+	delimiter := callCmd.String("delimiter", "", "print this line after each answer")
+	sourceList := callCmd.String("sources", "", "comma-separated list of documents to restrict the answer to")
+	batchFile := callCmd.String("batch", "", "answer the questions in this file, one per line, and write the answers as JSON lines")
+	output := callCmd.String("o", "", "with --batch, write the answers to this file instead of standard output")
+	concurrency := callCmd.Int("concurrency", 4, "with --batch, number of questions to answer at a time")
+	noCache := callCmd.Bool("no-cache", false, "always generate a new answer instead of reusing a cached one")
+	callCmd.Parse(args)
+
+	if *batchFile != "" {
+		*questionFile = *batchFile
+	}
+	questions, err := getQuestions(callCmd.Arg(0), *questionFile)
 	if err != nil {
-		log.Println("unable to create a temporary directory:", err)
-		return "", err
+		fail("cannot read questions", err)
 	}
-	defer os.RemoveAll(tempdir)
 
-	cmd := exec.Command(filepath.Join("bin", "pdftotext"), inputpdf, filepath.Join(tempdir, "output.txt"))
-	_, err = cmd.CombinedOutput()
-	if err != nil {
-		log.Printf("Command error: %s\n", err)
-		return "", err
+	log.Println("calling model with document")
+	var opts []vdb.Option
+	if !*noCache {
+		cache, err := vdb.OpenAnswerCache(cachePath)
+		if err != nil {
+			fail("cannot open answer cache", err)
+		}
+		opts = append(opts, vdb.WithAnswerCache(cache))
+	}
+	client := openClient(true, opts...)
+	sources := parseSources(client.Store(), *sourceList)
+	if err := checkSources(client.Store(), sources); err != nil {
+		fail("cannot restrict sources", err)
 	}
+	waitForOllama(ctx)
+	if *batchFile != "" {
+		batchCall(ctx, client, questions, sources, *output, *concurrency)
+		return
+	}
+	failed := 0
+	for _, question := range questions {
+		if err := ctx.Err(); err != nil {
+			fail("cannot answer questions", err)
+		}
+		if jsonOutput {
+			if !callJSON(ctx, client, question, sources) {
+				failed++
+			}
+			continue
+		}
+		_, err := client.Ask(ctx, question, sources, func(chunk string) {
+			fmt.Print(chunk)
+		})
+		fmt.Println()
+		if err != nil {
+			failed++
+			log.Println(err)
+			if _, hint := describeError(err); hint != "" {
+				log.Println(hint)
+			}
+		}
+		if *delimiter != "" {
+			fmt.Println(*delimiter)
+		}
+	}
+	if failed > 0 {
+		log.Printf("%d of %d questions could not be answered\n", failed, len(questions))
+		os.Exit(1)
+	}
+}
 
-	text, err := os.ReadFile(filepath.Join(tempdir, "output.txt"))
+// answers the question and prints the answer, the sources it was based
+// on and the token usage as a JSON object, returning false if it could not
+// be answered
+func callJSON(ctx context.Context, client *vdb.Client, question string, sources []string) bool {
+	result := answerResult(ctx, client, question, sources)
+	printJSON(result)
+	_, failed := result["error"]
+	return !failed
+}
+
+// answers the question, returning the answer, the sources it was based on
+// and the token usage, or the error, for JSON output
+func answerResult(ctx context.Context, client *vdb.Client, question string, sources []string) map[string]any {
+	result := map[string]any{
+		"question": question,
+	}
+	answer, err := client.Ask(ctx, question, sources, nil)
 	if err != nil {
-		log.Printf("cannot read text: %s\n", err)
-		return "", err
+		result["error"] = err.Error()
+		result["kind"], _ = describeError(err)
+	} else {
+		result["answer"] = answer.Text
+		result["sources"] = chunkSources(answer.Sources)
+		result["usage"] = answer.Usage
+		result["cached"] = answer.Cached
 	}
-	content := string(text)
-	content = strings.ToValidUTF8(content, "")
-	return content, nil
+	return result
 }
 
-// splits up the content and cleans it up
-// by removing duplicates and very chunks
-func clean(content string) []string {
-	split := strings.Split(content, "\n\n")
-	cleaned := []string{}
-	for _, s := range split {
-		cleaned = append(cleaned, strings.TrimSpace(s))
-	}
-	unique := removeDuplicates(cleaned)
-	shortRemoved := removeShortStrings(unique)
-	return shortRemoved
+// a chunk used to answer a question, as reported in JSON output
+type chunkSource struct {
+	ID     string  `json:"id"`
+	Source string  `json:"source"`
+	Score  float32 `json:"score"`
 }
 
-func removeDuplicates(s []string) []string {
-	m := make(map[string]bool)
-	result := []string{}
-	for _, item := range s {
-		if _, ok := m[item]; !ok {
-			m[item] = true
-			result = append(result, item)
-		}
+func chunkSources(chunks []vdb.ScoredChunk) []chunkSource {
+	sources := make([]chunkSource, 0, len(chunks))
+	for _, chunk := range chunks {
+		sources = append(sources, chunkSource{ID: chunk.ID, Source: chunk.Source, Score: chunk.Score})
 	}
-	return result
+	return sources
 }
 
-func removeShortStrings(slice []string) []string {
-	var result []string
-	for _, str := range slice {
-		sl := strings.Split(str, " ")
-		if len(sl) > 3 {
-			result = append(result, str)
+func lsCommand(args []string) {
+	lsCmd := newFlagSet("ls")
+	lsCmd.Parse(args)
+
+	documents := openClient(false).Store().Documents()
+	if jsonOutput {
+		if documents == nil {
+			documents = []vdb.DocumentInfo{}
 		}
+		printJSON(documents)
+		return
+	}
+	for _, d := range documents {
+		fmt.Printf("%-40s %d chunks\n", d.Name, d.Chunks)
 	}
-	return result
 }
 
-// dot product of 2 float32 slices
-func dotproduct(a, b []float32) float32 {
-	if len(a) != len(b) {
-		return 0.0
+func reembedCommand(ctx context.Context, args []string) {
+	reembedCmd := newFlagSet("reembed")
+	model := reembedCmd.String("model", "", "the embedding model to re-embed the stored chunks with")
+	batchSize := reembedCmd.Int("batch", vdb.DefaultReembedBatchSize, "number of chunks to embed at a time")
+	reembedCmd.Parse(args)
+	if *model == "" {
+		fmt.Fprintln(os.Stderr, "usage: vdb reembed --model <model> [--batch n]")
+		os.Exit(2)
 	}
-	var dp float32
-	for i := 0; i < len(a); i++ {
-		dp += a[i] * b[i]
+
+	store := openClient(true).Store()
+	waitForOllama(ctx)
+	previous := store.EmbeddingModel()
+	err := store.Reembed(ctx, vdb.NewOllamaEmbedder(*model), *model, vdb.ReembedOptions{
+		BatchSize: *batchSize,
+		Progress: func(done, total int) {
+			log.Printf("re-embedded %d of %d chunks\n", done, total)
+		},
+	})
+	if err != nil {
+		fail("cannot re-embed store, run the command again to resume", err)
 	}
-	return dp
+	if jsonOutput {
+		printJSON(map[string]any{
+			"previous_model": previous,
+			"model":          *model,
+			"chunks":         store.Len(),
+			"dimension":      store.Dimension(),
+		})
+		return
+	}
+	log.Printf("re-embedded %d chunks with %s\n", store.Len(), *model)
 }
 
-// magnitude of a float32 slice
-func magnitude(a []float32) float32 {
-	var mag float64
-	for i := 0; i < len(a); i++ {
-		mag += math.Pow(float64(a[i]), 2.0)
+// splits a comma-separated list of document names, as given to --sources.
+// A path is taken as the name of the document added from it, unless the
+// store has a document with that exact name, as synced from a directory
+func parseSources(store *vdb.Store, list string) []string {
+	var sources []string
+	for _, source := range splitList(list) {
+		if !store.HasDocument(source) {
+			source = filepath.Base(source)
+		}
+		sources = append(sources, source)
 	}
-	return float32(mag)
+	return sources
 }
 
-// cosine similarity of 2 float32 slices
-func similarity(a, b []float32) float32 {
-	return dotproduct(a, b) / (magnitude(a) * magnitude(b))
+// splits a comma-separated flag value, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-// get embeddings from Ollama
-func getEmbeddings(content []string) ([][]float32, error) {
-	llm, err := ollama.New(ollama.WithModel("nomic-embed-text"))
-	if err != nil {
-		return [][]float32{}, err
+// checks that every source names a document in the store
+func checkSources(store *vdb.Store, sources []string) error {
+	for _, source := range sources {
+		if !store.HasDocument(source) {
+			return fmt.Errorf("unknown source %q, see vdb ls for the stored documents", source)
+		}
 	}
-	c := context.Background()
-	return llm.CreateEmbedding(c, content)
+	return nil
+}
+
+// what adding a document would store, as reported by a dry run
+type dryRunReport struct {
+	Chunks                  int      `json:"chunks"`
+	Bytes                   int      `json:"bytes"`
+	EstimatedEmbeddingCalls int      `json:"estimated_embedding_calls"`
+	EmbeddingDimension      int      `json:"embedding_dimension,omitempty"`
+	Previews                []string `json:"previews"`
 }
 
-// get chunks that are similar to the given question
-func getSimilarChunks(question string) []string {
-	chunks := make(map[float32]string)
-	embedding, _ := getEmbeddings([]string{question})
-	for _, doc := range vdb {
-		sim := similarity(embedding[0], doc.Embedding)
-		chunks[sim] = doc.Content
+// reports what adding the content would store, without writing vdb.gob.
+// If probe is set, the first chunk is embedded to check that the embedding
+// model is reachable and to report the embedding dimension
+func dryRunAdd(ctx context.Context, client *vdb.Client, content []string, probe bool) {
+	report := dryRunReport{
+		Chunks: len(content),
+		// Ollama embeddings are created with one call per chunk
+		EstimatedEmbeddingCalls: len(content),
+		Previews:                []string{},
+	}
+	for _, c := range content {
+		report.Bytes += len(c)
+		report.Previews = append(report.Previews, preview(c, 80))
+	}
+
+	if probe && len(content) > 0 {
+		embeddings, err := client.Embed(ctx, content[:1])
+		if err != nil {
+			fail("embedding probe failed", err)
+		}
+		report.EmbeddingDimension = len(embeddings[0])
 	}
 
-	// return top 3 chunks
-	keys := make([]float32, 0, len(chunks))
-	for k := range chunks {
-		keys = append(keys, k)
+	if jsonOutput {
+		printJSON(report)
+		return
 	}
-	sort.Slice(keys, func(i, j int) bool {
-		return keys[i] > keys[j]
-	})
-	var topChunks []string
-	for _, key := range keys[:3] {
-		topChunks = append(topChunks, chunks[key])
+	fmt.Printf("chunks to store:           %d\n", report.Chunks)
+	fmt.Printf("total content size:        %d bytes\n", report.Bytes)
+	fmt.Printf("estimated embedding calls: %d\n", report.EstimatedEmbeddingCalls)
+	if report.EmbeddingDimension > 0 {
+		fmt.Printf("embedding dimension:       %d\n", report.EmbeddingDimension)
+	}
+	for i, p := range report.Previews {
+		fmt.Printf("\n[%d] %s\n", i, p)
 	}
-	return topChunks
 }
 
-// call the Ollama model with the doc and the question
-func call(model string, doc string, question string) {
-	llm, err := ollama.New(ollama.WithModel(model))
-	if err != nil {
-		log.Println("Cannot create LLM:", err)
+// shortens s to at most n characters for display
+func preview(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	r := []rune(s)
+	if len(r) <= n {
+		return s
 	}
-	c := context.Background()
-	_, err = llm.GenerateContent(
-		c, []llms.MessageContent{
-			llms.TextParts(schema.ChatMessageTypeSystem, doc),
-			llms.TextParts(schema.ChatMessageTypeHuman, question),
-		},
-		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
-			fmt.Print(string(chunk))
-			return nil
-		}), llms.WithMinLength(1024),
-	)
-	fmt.Println()
-	if err != nil {
-		log.Println("Cannot generate content:", err)
+	return string(r[:n]) + "..."
+}
+
+// gets the questions to ask: from the given file if there is one, from
+// standard input if the argument is "-", otherwise the argument itself
+func getQuestions(arg string, filename string) ([]string, error) {
+	if filename != "" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readQuestions(file)
+	}
+	if arg == "-" {
+		return readQuestions(os.Stdin)
+	}
+	if arg == "" {
+		return nil, errors.New("no question given")
+	}
+	return []string{arg}, nil
+}
+
+// reads one question per line, skipping blank lines
+func readQuestions(r io.Reader) ([]string, error) {
+	var questions []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			questions = append(questions, line)
+		}
 	}
+	return questions, scanner.Err()
 }
@@ -1,22 +1,66 @@
 package main
 
 import (
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/jmorganca/ollama/server"
+	"github.com/sausheong/vdb/pkg/vdb"
 	"golang.org/x/crypto/ssh"
 )
 
-// the code below are taken from Ollama
-// start the OllamaServer
-func startOllamaServer() error {
+// how long to wait for the Ollama server to be ready before giving up
+var ollamaStartTimeout = 30 * time.Second
+
+// receives the error if the Ollama server started by startOllamaServer
+// fails. Nothing is sent while it is serving, if it stops without an error,
+// or if another Ollama server is already listening on the address
+var ollamaServerErr = make(chan error, 1)
+
+// starts the Ollama server in the background
+func runOllamaServer() {
+	go func() {
+		err := startOllamaServer()
+		if err == nil || errors.Is(err, syscall.EADDRINUSE) {
+			// stopped serving, or there is already a server to use
+			return
+		}
+		ollamaServerErr <- err
+	}()
+}
+
+// waits until the Ollama server answers, exiting if it fails to start or
+// is not ready within ollamaStartTimeout
+func waitForOllama(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, ollamaStartTimeout)
+	defer cancel()
+	ready := make(chan error, 1)
+	go func() {
+		ready <- vdb.WaitForOllama(ctx, "http://"+ollamaAddress())
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			fail("Ollama is not ready", err)
+		}
+	case err := <-ollamaServerErr:
+		fail("cannot start Ollama server", fmt.Errorf("%w: %w", vdb.ErrOllamaUnavailable, err))
+	}
+}
+
+// the address the Ollama server listens on, from OLLAMA_HOST
+func ollamaAddress() string {
 	host, port, err := net.SplitHostPort(os.Getenv("OLLAMA_HOST"))
 	if err != nil {
 		host, port = "127.0.0.1", "11434"
@@ -24,12 +68,17 @@ func startOllamaServer() error {
 			host = ip.String()
 		}
 	}
+	return net.JoinHostPort(host, port)
+}
 
+// the code below are taken from Ollama
+// start the OllamaServer
+func startOllamaServer() error {
 	if err := initializeKeypair(); err != nil {
 		return err
 	}
 
-	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	ln, err := net.Listen("tcp", ollamaAddress())
 	if err != nil {
 		return err
 	}
@@ -49,7 +98,7 @@ func initializeKeypair() error {
 
 	_, err = os.Stat(privKeyPath)
 	if os.IsNotExist(err) {
-		fmt.Printf("Couldn't find '%s'. Generating new private key.\n", privKeyPath)
+		fmt.Fprintf(os.Stderr, "Couldn't find '%s'. Generating new private key.\n", privKeyPath)
 		cryptoPublicKey, cryptoPrivateKey, err := ed25519.GenerateKey(rand.Reader)
 		if err != nil {
 			return err
@@ -79,7 +128,7 @@ func initializeKeypair() error {
 			return err
 		}
 
-		fmt.Printf("Your new public key is: \n\n%s\n", publicKeyBytes)
+		fmt.Fprintf(os.Stderr, "Your new public key is: \n\n%s\n", publicKeyBytes)
 	}
 	return nil
 }
@@ -0,0 +1,106 @@
+package vdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// AnswerCache keeps the answers to questions so that asking the same
+// question again returns the earlier answer without retrieving or
+// generating anything. Answers are keyed on the store, the model, the
+// sources the answer was restricted to and the question, ignoring case and
+// spacing, and are only returned while the store's Version is unchanged.
+// It is safe for concurrent use.
+type AnswerCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cacheEntry
+}
+
+// a cached answer and the version of the store it was made from
+type cacheEntry struct {
+	Version string
+	Answer  Answer
+}
+
+// OpenAnswerCache loads the answer cache saved at path, or returns an empty
+// one if there is no file at path yet. If path is empty, the cache is only
+// kept in memory.
+func OpenAnswerCache(path string) (*AnswerCache, error) {
+	c := &AnswerCache{path: path, entries: map[string]cacheEntry{}}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&c.entries); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// the cache key for a question asked of a client
+func (c *Client) cacheKey(question string, sources []string) string {
+	sources = append([]string(nil), sources...)
+	sort.Strings(sources)
+	h := sha256.New()
+	for _, part := range []string{c.store.Path(), c.model, strings.Join(sources, "\x00"), normalizeQuestion(question)} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// returns the cached answer for the key if it was made from the given
+// version of the store
+func (c *AnswerCache) get(key string, version string) (*Answer, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.Version != version {
+		return nil, false
+	}
+	answer := entry.Answer
+	// the caller may change the answer, which must not change the cache
+	answer.Sources = append([]ScoredChunk(nil), entry.Answer.Sources...)
+	return &answer, true
+}
+
+// caches the answer and saves the cache. Entries made from other versions
+// of the store can no longer be returned, so they are dropped
+func (c *AnswerCache) put(key string, version string, answer *Answer) error {
+	cached := *answer
+	cached.Sources = make([]ScoredChunk, len(answer.Sources))
+	for i, chunk := range answer.Sources {
+		// the embeddings are not needed to report the sources
+		chunk.Embedding = nil
+		cached.Sources[i] = chunk
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if entry.Version != version {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{Version: version, Answer: cached}
+	if c.path == "" {
+		return nil
+	}
+	return writeGob(c.path, c.entries)
+}
@@ -0,0 +1,137 @@
+package vdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// a client with two documents whose answers are generated without a model,
+// counting how many it generates
+func newCacheClient(t *testing.T, opts ...Option) (*Client, *int) {
+	t.Helper()
+	store := NewStore("")
+	err := store.Add(
+		NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0}),
+		NewChunk("policy.pdf", "expenses are approved by managers", []float32{0, 1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		embeddings := make([][]float32, len(texts))
+		for i := range texts {
+			embeddings[i] = []float32{1, 0}
+		}
+		return embeddings, nil
+	})
+	client := NewClient(store, append([]Option{WithEmbedder(embedder)}, opts...)...)
+	generated := 0
+	client.generate = func(ctx context.Context, question string, chunks []ScoredChunk, stream func(string)) (*Answer, error) {
+		generated++
+		return &Answer{Question: question, Text: "18 days", Sources: chunks}, nil
+	}
+	return client, &generated
+}
+
+func newTestCache(t *testing.T) *AnswerCache {
+	t.Helper()
+	cache, err := OpenAnswerCache(filepath.Join(t.TempDir(), "cache.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func mustAsk(t *testing.T, client *Client, question string, sources ...string) *Answer {
+	t.Helper()
+	answer, err := client.Ask(context.Background(), question, sources, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return answer
+}
+
+func TestAskReturnsCachedAnswer(t *testing.T) {
+	cache := newTestCache(t)
+	client, generated := newCacheClient(t, WithAnswerCache(cache))
+	if answer := mustAsk(t, client, "How much annual leave?"); answer.Cached {
+		t.Error("first answer is cached")
+	}
+
+	var streamed string
+	answer, err := client.Ask(context.Background(), "How much annual leave?", nil, func(s string) { streamed += s })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !answer.Cached || answer.Text != "18 days" || len(answer.Sources) == 0 {
+		t.Errorf("second answer = %+v, want the cached answer", answer)
+	}
+	if streamed != "18 days" {
+		t.Errorf("streamed %q, want the whole cached answer", streamed)
+	}
+	if *generated != 1 {
+		t.Errorf("generated %d answers, want 1", *generated)
+	}
+
+	// the cache is saved, so it is kept between runs
+	reopened, err := OpenAnswerCache(cache.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.cache = reopened
+	if answer := mustAsk(t, client, "How much annual leave?"); !answer.Cached {
+		t.Error("answer not cached after reopening the cache")
+	}
+}
+
+func TestCacheIsInvalidatedWhenStoreChanges(t *testing.T) {
+	client, generated := newCacheClient(t, WithAnswerCache(newTestCache(t)))
+	mustAsk(t, client, "How much annual leave?")
+	if err := client.Store().Add(NewChunk("handbook.pdf", "annual leave is now 20 days", []float32{1, 0})); err != nil {
+		t.Fatal(err)
+	}
+	if answer := mustAsk(t, client, "How much annual leave?"); answer.Cached {
+		t.Error("answer from before the store changed was cached")
+	}
+	if *generated != 2 {
+		t.Errorf("generated %d answers, want 2", *generated)
+	}
+}
+
+func TestCacheKeyIgnoresCaseSpacingAndSourceOrder(t *testing.T) {
+	client, generated := newCacheClient(t, WithAnswerCache(newTestCache(t)))
+	mustAsk(t, client, "How much annual leave?", "handbook.pdf", "policy.pdf")
+	if answer := mustAsk(t, client, "  how much  ANNUAL leave? ", "policy.pdf", "handbook.pdf"); !answer.Cached {
+		t.Error("same question asked differently was not cached")
+	}
+	if answer := mustAsk(t, client, "How much annual leave?", "handbook.pdf"); answer.Cached {
+		t.Error("question restricted to other sources was cached")
+	}
+	if *generated != 2 {
+		t.Errorf("generated %d answers, want 2", *generated)
+	}
+}
+
+func TestAskWithoutCacheAlwaysGenerates(t *testing.T) {
+	// as vdb call --no-cache does
+	client, generated := newCacheClient(t)
+	for i := 0; i < 2; i++ {
+		if answer := mustAsk(t, client, "How much annual leave?"); answer.Cached {
+			t.Errorf("answer %d is cached", i+1)
+		}
+	}
+	if *generated != 2 {
+		t.Errorf("generated %d answers, want 2", *generated)
+	}
+}
+
+func TestCachedAnswerSourcesAreCopies(t *testing.T) {
+	client, _ := newCacheClient(t, WithAnswerCache(newTestCache(t)))
+	mustAsk(t, client, "How much annual leave?")
+	first := mustAsk(t, client, "How much annual leave?")
+	first.Sources[0].Content = "changed by the caller"
+	if second := mustAsk(t, client, "How much annual leave?"); second.Sources[0].Content == "changed by the caller" {
+		t.Error("changing a cached answer's sources changed the cache")
+	}
+}
@@ -0,0 +1,309 @@
+package vdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/schema"
+)
+
+const (
+	// DefaultModel is the Ollama model used to generate answers.
+	DefaultModel = "llama2"
+	// DefaultEmbeddingModel is the Ollama model used to embed chunks.
+	DefaultEmbeddingModel = "nomic-embed-text"
+	// DefaultK is the number of chunks used as context for an answer.
+	DefaultK = 3
+)
+
+// Client ingests documents into a store and answers questions about them
+// using Ollama models.
+type Client struct {
+	store    *Store
+	model    string
+	embedder Embedder
+	timeouts Timeouts
+	queryLog *queryLog
+	cache    *AnswerCache
+	llm      ollamaLLM
+	// generates the answers for Ask; Generate unless replaced in tests
+	generate func(ctx context.Context, question string, chunks []ScoredChunk, stream func(string)) (*Answer, error)
+}
+
+// Timeouts limits how long each stage of ingestion and answering may take.
+// A zero duration means the stage is only limited by the caller's context.
+type Timeouts struct {
+	// Convert limits converting a document to text.
+	Convert time.Duration
+	// Embed limits embedding the chunks of a document.
+	Embed time.Duration
+	// Search limits embedding a question and searching the store.
+	Search time.Duration
+	// Generate limits generating an answer.
+	Generate time.Duration
+}
+
+// returns a context for a stage that is cancelled after timeout, if set
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithModel sets the Ollama model used to generate answers.
+func WithModel(model string) Option {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithEmbeddingModel sets the Ollama model used to embed chunks and
+// questions.
+func WithEmbeddingModel(model string) Option {
+	return func(c *Client) {
+		c.embedder = NewOllamaEmbedder(model)
+	}
+}
+
+// WithEmbedder sets the embedder used to embed chunks and questions,
+// replacing Ollama.
+func WithEmbedder(embedder Embedder) Option {
+	return func(c *Client) {
+		c.embedder = embedder
+	}
+}
+
+// WithTimeouts sets the per-stage timeouts.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(c *Client) {
+		c.timeouts = timeouts
+	}
+}
+
+// WithQueryLog records every question asked with Ask to w as a line of
+// JSON, with the retrieval scores and how long answering took. The log can
+// be read back with ReadQueryLog.
+func WithQueryLog(w io.Writer) Option {
+	return func(c *Client) {
+		c.queryLog = &queryLog{encoder: json.NewEncoder(w)}
+	}
+}
+
+// WithAnswerCache makes Ask return answers from the cache for questions
+// already answered, and add new answers to it.
+func WithAnswerCache(cache *AnswerCache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// NewClient returns a client for the given store. Unless another embedder
+// is given, chunks and questions are embedded with the store's embedding
+// model, or DefaultEmbeddingModel if the store does not record one.
+func NewClient(store *Store, opts ...Option) *Client {
+	embeddingModel := store.EmbeddingModel()
+	if embeddingModel == "" {
+		embeddingModel = DefaultEmbeddingModel
+	}
+	c := &Client{
+		store:    store,
+		model:    DefaultModel,
+		embedder: NewOllamaEmbedder(embeddingModel),
+	}
+	c.generate = c.Generate
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Store returns the store the client reads and writes.
+func (c *Client) Store() *Store {
+	return c.store
+}
+
+// Usage holds the token counts reported by the model for an answer.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Answer is the answer to a question and the chunks it was based on.
+type Answer struct {
+	Question string
+	Text     string
+	Sources  []ScoredChunk
+	Usage    Usage
+	// Cached is set if the answer came from the client's answer cache.
+	Cached bool
+}
+
+// Embed gets embeddings for the contents from the client's embedder.
+// Failures are reported as ErrEmbeddingFailed.
+func (c *Client) Embed(ctx context.Context, contents []string) ([][]float32, error) {
+	embeddings, err := c.embedder.Embed(ctx, contents)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	if len(embeddings) != len(contents) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d texts", ErrEmbeddingFailed, len(embeddings), len(contents))
+	}
+	return embeddings, nil
+}
+
+// Pipeline returns an ingestion pipeline using the client's store, embedder
+// and timeouts. Its stages can be customised before it is run.
+func (c *Client) Pipeline() *Pipeline {
+	return NewPipeline(c.store, c.embedder).WithTimeouts(c.timeouts)
+}
+
+// Convert converts the file to text, within the convert timeout.
+func (c *Client) Convert(ctx context.Context, filename string) (string, error) {
+	doc, err := c.Pipeline().Convert(ctx, filename)
+	if err != nil {
+		return "", err
+	}
+	return doc.Text, nil
+}
+
+// Ingest converts the file, splits it into chunks and adds them to the
+// store under the file's base name, returning the IDs of the added chunks.
+func (c *Client) Ingest(ctx context.Context, filename string) ([]string, error) {
+	return c.Pipeline().Run(ctx, filename)
+}
+
+// AddTexts embeds the texts, adds them to the store as chunks of the source
+// document and saves the store, returning the IDs of the added chunks.
+func (c *Client) AddTexts(ctx context.Context, source string, texts []string) ([]string, error) {
+	chunks := make([]Chunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = NewChunk(source, text, nil)
+	}
+	return c.Pipeline().Store(ctx, chunks)
+}
+
+// Retriever returns a retriever for the k chunks most similar to a question,
+// optionally restricted to the given source documents.
+func (c *Client) Retriever(k int, sources ...string) *Retriever {
+	return &Retriever{
+		Store:    c.store,
+		Embedder: EmbedderFunc(c.Embed),
+		K:        k,
+		Sources:  sources,
+		Timeout:  c.timeouts.Search,
+	}
+}
+
+// Ask answers the question using the DefaultK most similar chunks, from
+// the given sources only if there are any, as context. If stream is not nil
+// it is called with each piece of the answer as it is generated, or with
+// the whole answer if it is cached.
+func (c *Client) Ask(ctx context.Context, question string, sources []string, stream func(string)) (*Answer, error) {
+	record := QueryRecord{Time: time.Now(), Question: question, Sources: sources}
+	answer, err := c.ask(ctx, question, sources, stream, &record)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if c.queryLog != nil {
+		if logErr := c.queryLog.write(record); logErr != nil && err == nil {
+			err = fmt.Errorf("cannot write query log: %w", logErr)
+		}
+	}
+	return answer, err
+}
+
+// answers the question as Ask does, recording the retrieval and timings
+func (c *Client) ask(ctx context.Context, question string, sources []string, stream func(string), record *QueryRecord) (*Answer, error) {
+	var key, version string
+	if c.cache != nil {
+		key, version = c.cacheKey(question, sources), c.store.Version()
+		if answer, ok := c.cache.get(key, version); ok {
+			answer.Cached = true
+			record.Cached = true
+			record.Chunks = len(answer.Sources)
+			if len(answer.Sources) > 0 {
+				record.TopScore = answer.Sources[0].Score
+			}
+			if stream != nil {
+				stream(answer.Text)
+			}
+			return answer, nil
+		}
+	}
+
+	start := time.Now()
+	chunks, err := c.Retriever(DefaultK, sources...).Retrieve(ctx, question)
+	record.Retrieval = time.Since(start).Milliseconds()
+	if err != nil {
+		return nil, fmt.Errorf("cannot search chunks: %w", err)
+	}
+	record.Chunks = len(chunks)
+	if len(chunks) > 0 {
+		record.TopScore = chunks[0].Score
+	}
+
+	start = time.Now()
+	answer, err := c.generate(ctx, question, chunks, stream)
+	record.Generation = time.Since(start).Milliseconds()
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil {
+		if err := c.cache.put(key, version, answer); err != nil {
+			return answer, fmt.Errorf("cannot cache answer: %w", err)
+		}
+	}
+	return answer, nil
+}
+
+// Generate answers the question using the chunks as context. If stream is
+// not nil it is called with each piece of the answer as it is generated.
+func (c *Client) Generate(ctx context.Context, question string, chunks []ScoredChunk, stream func(string)) (*Answer, error) {
+	llm, err := c.llm.get(c.model)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeouts.Generate)
+	defer cancel()
+
+	var contents []string
+	for _, chunk := range chunks {
+		contents = append(contents, chunk.Content)
+	}
+	resp, err := llm.GenerateContent(
+		ctx, []llms.MessageContent{
+			llms.TextParts(schema.ChatMessageTypeSystem, strings.Join(contents, "\n")),
+			llms.TextParts(schema.ChatMessageTypeHuman, question),
+		},
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			if stream != nil {
+				stream(string(chunk))
+			}
+			return nil
+		}), llms.WithMinLength(1024),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate content: %w", err)
+	}
+
+	choice := resp.Choices[0]
+	answer := &Answer{
+		Question: question,
+		Text:     choice.Content,
+		Sources:  chunks,
+	}
+	answer.Usage.PromptTokens, _ = choice.GenerationInfo["PromptTokens"].(int)
+	answer.Usage.CompletionTokens, _ = choice.GenerationInfo["CompletionTokens"].(int)
+	answer.Usage.TotalTokens = answer.Usage.PromptTokens + answer.Usage.CompletionTokens
+	return answer, nil
+}
@@ -0,0 +1,202 @@
+package vdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// the most k-means iterations run if the assignments keep changing
+const maxKMeansIterations = 100
+
+// Cluster is a group of chunks with similar embeddings.
+type Cluster struct {
+	// Label is a short name for the topic of the cluster, set by
+	// Client.LabelCluster.
+	Label    string
+	Centroid []float32
+	// Chunks are the chunks in the cluster, scored by their similarity to
+	// the centroid, most representative first.
+	Chunks []ScoredChunk
+}
+
+// KMeans groups the chunks into at most k clusters by cosine similarity
+// of their embeddings, using k-means++ seeded with seed to choose the
+// starting centroids. Clusters are returned largest first.
+func KMeans(chunks []Chunk, k int, seed int64) ([]Cluster, error) {
+	if k <= 0 {
+		return nil, errors.New("k must be positive")
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	k = min(k, len(chunks))
+	points := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		points[i] = normalize(chunk.Embedding)
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	centroids := initCentroids(points, k, random)
+	assignments := make([]int, len(points))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	for iteration := 0; iteration < maxKMeansIterations; iteration++ {
+		changed := false
+		for i, point := range points {
+			if nearest := nearestCentroid(point, centroids); nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		centroids = updateCentroids(points, assignments, centroids, random)
+	}
+
+	clusters := make([]Cluster, len(centroids))
+	for i := range clusters {
+		clusters[i].Centroid = centroids[i]
+	}
+	for i, chunk := range chunks {
+		c := &clusters[assignments[i]]
+		c.Chunks = append(c.Chunks, ScoredChunk{Score: dotproduct(points[i], c.Centroid), Chunk: chunk})
+	}
+
+	var result []Cluster
+	for _, c := range clusters {
+		if len(c.Chunks) == 0 {
+			continue
+		}
+		sort.Slice(c.Chunks, func(i, j int) bool {
+			return c.Chunks[i].Score > c.Chunks[j].Score
+		})
+		result = append(result, c)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].Chunks) > len(result[j].Chunks)
+	})
+	return result, nil
+}
+
+// returns a unit length copy of v, so that the dot product of two
+// normalized vectors is their cosine similarity
+func normalize(v []float32) []float32 {
+	n := make([]float32, len(v))
+	mag := magnitude(v)
+	if mag == 0 {
+		return n
+	}
+	for i := range v {
+		n[i] = v[i] / mag
+	}
+	return n
+}
+
+// the cosine distance between two normalized vectors
+func distance(a, b []float32) float64 {
+	return math.Max(0, 1-float64(dotproduct(a, b)))
+}
+
+// chooses k starting centroids with k-means++: each after the first is
+// picked with probability proportional to its squared distance from the
+// nearest centroid picked so far
+func initCentroids(points [][]float32, k int, random *rand.Rand) [][]float32 {
+	centroids := [][]float32{points[random.Intn(len(points))]}
+	distances := make([]float64, len(points))
+	for len(centroids) < k {
+		var total float64
+		for i, point := range points {
+			d := distance(point, centroids[nearestCentroid(point, centroids)])
+			distances[i] = d * d
+			total += distances[i]
+		}
+		if total == 0 {
+			// every point is already a centroid
+			break
+		}
+		target := random.Float64() * total
+		next := len(points) - 1
+		for i, d := range distances {
+			if target -= d; target <= 0 {
+				next = i
+				break
+			}
+		}
+		centroids = append(centroids, points[next])
+	}
+	return centroids
+}
+
+func nearestCentroid(point []float32, centroids [][]float32) int {
+	nearest, best := 0, float32(math.Inf(-1))
+	for i, centroid := range centroids {
+		if score := dotproduct(point, centroid); score > best {
+			nearest, best = i, score
+		}
+	}
+	return nearest
+}
+
+// moves each centroid to the normalized mean of the points assigned to it.
+// A centroid with no points is moved to a random point instead
+func updateCentroids(points [][]float32, assignments []int, centroids [][]float32, random *rand.Rand) [][]float32 {
+	dimension := len(points[0])
+	sums := make([][]float32, len(centroids))
+	counts := make([]int, len(centroids))
+	for i := range sums {
+		sums[i] = make([]float32, dimension)
+	}
+	for i, point := range points {
+		c := assignments[i]
+		counts[c]++
+		for d := range point {
+			sums[c][d] += point[d]
+		}
+	}
+	updated := make([][]float32, len(centroids))
+	for i := range sums {
+		if counts[i] == 0 {
+			updated[i] = points[random.Intn(len(points))]
+			continue
+		}
+		updated[i] = normalize(sums[i])
+	}
+	return updated
+}
+
+// LabelCluster asks the client's model for a short topic name for the
+// cluster, based on its samples most representative chunks.
+func (c *Client) LabelCluster(ctx context.Context, cluster Cluster, samples int) (string, error) {
+	llm, err := c.llm.get(c.model)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeouts.Generate)
+	defer cancel()
+
+	var prompt strings.Builder
+	prompt.WriteString("The following passages are about the same topic. ")
+	prompt.WriteString("Reply with only a short name for the topic, in at most 5 words.\n")
+	for _, chunk := range cluster.Chunks[:min(samples, len(cluster.Chunks))] {
+		prompt.WriteString("\n---\n")
+		prompt.WriteString(chunk.Content)
+	}
+	label, err := llms.GenerateFromSinglePrompt(ctx, llm, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("cannot generate label: %w", err)
+	}
+	label = strings.TrimSpace(label)
+	if line, _, ok := strings.Cut(label, "\n"); ok {
+		label = line
+	}
+	return strings.Trim(label, `"'*. `), nil
+}
@@ -0,0 +1,72 @@
+package vdb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// chunks in separable groups, one per axis of a 3D space, each named by
+// its group
+func groupedChunks(perGroup int, seed int64) []Chunk {
+	random := rand.New(rand.NewSource(seed))
+	var chunks []Chunk
+	for group, source := range []string{"x.pdf", "y.pdf", "z.pdf"} {
+		for i := 0; i < perGroup; i++ {
+			embedding := make([]float32, 3)
+			for d := range embedding {
+				embedding[d] = float32(random.Float64() * 0.1)
+			}
+			embedding[group] += 1
+			chunks = append(chunks, NewChunk(source, string(rune('a'+i)), embedding))
+		}
+	}
+	return chunks
+}
+
+func TestKMeansSeparatesGroups(t *testing.T) {
+	chunks := groupedChunks(5, 1)
+	clusters, err := KMeans(chunks, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 3 {
+		t.Fatalf("got %d clusters, want 3", len(clusters))
+	}
+	seen := map[string]bool{}
+	for i, c := range clusters {
+		if len(c.Chunks) != 5 {
+			t.Errorf("cluster %d has %d chunks, want 5", i, len(c.Chunks))
+		}
+		source := c.Chunks[0].Source
+		if seen[source] {
+			t.Errorf("cluster %d repeats group %s", i, source)
+		}
+		seen[source] = true
+		for j, chunk := range c.Chunks {
+			if chunk.Source != source {
+				t.Errorf("cluster %d mixes %s and %s", i, source, chunk.Source)
+			}
+			if j > 0 && chunk.Score > c.Chunks[j-1].Score {
+				t.Errorf("cluster %d chunk %d scores higher than the one before it", i, j)
+			}
+		}
+	}
+}
+
+func TestKMeansLimits(t *testing.T) {
+	chunks := groupedChunks(1, 1)
+	clusters, err := KMeans(chunks, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != len(chunks) {
+		t.Errorf("got %d clusters of %d chunks, want one each", len(clusters), len(chunks))
+	}
+
+	if _, err := KMeans(chunks, 0, 1); err == nil {
+		t.Error("k of 0 was accepted")
+	}
+	if clusters, err := KMeans(nil, 3, 1); err != nil || len(clusters) != 0 {
+		t.Errorf("KMeans of no chunks = %v, %v, want no clusters", clusters, err)
+	}
+}
@@ -0,0 +1,200 @@
+package vdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PdfToText is the path of xpdfreader's pdftotext, used to convert PDFs.
+var PdfToText = filepath.Join("bin", "pdftotext")
+
+// Converter converts a document file into text.
+type Converter interface {
+	Convert(ctx context.Context, filename string) (string, error)
+}
+
+// ConverterFunc is an adapter to allow the use of ordinary functions as
+// Converters.
+type ConverterFunc func(ctx context.Context, filename string) (string, error)
+
+// Convert calls f(ctx, filename).
+func (f ConverterFunc) Convert(ctx context.Context, filename string) (string, error) {
+	return f(ctx, filename)
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[string]Converter{
+		".pdf": ConverterFunc(convertPDF),
+	}
+)
+
+// RegisterConverter makes a converter available for files with the given
+// extension, such as ".rtf", replacing any converter already registered
+// for it.
+func RegisterConverter(ext string, converter Converter) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[normalizeExt(ext)] = converter
+}
+
+// Formats returns the file extensions there are converters for, sorted.
+func Formats() []string {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	var exts []string
+	for ext := range converters {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// Convert converts the file into text using the converter registered for
+// its extension. The conversion is stopped if ctx is cancelled. Files with
+// no registered converter are reported as ErrUnsupportedFormat.
+func Convert(ctx context.Context, filename string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	convertersMu.RLock()
+	converter, ok := converters[ext]
+	convertersMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, ext)
+	}
+
+	content, err := converter.Convert(ctx, filename)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToValidUTF8(content, ""), nil
+}
+
+// converts pdf into text using xpdfreader's pdftotext
+func convertPDF(ctx context.Context, inputpdf string) (string, error) {
+	converter := CommandConverter{Command: []string{PdfToText, "{input}", "{output}"}}
+	return converter.Convert(ctx, inputpdf)
+}
+
+// CommandConverter converts documents by running an external command. In
+// the command's arguments, {input} is replaced by the path of the document
+// and {output} by the path of a temporary file the command should write the
+// text to. If no argument uses {output}, the text is read from the
+// command's standard output instead.
+type CommandConverter struct {
+	Command []string
+}
+
+// Convert runs the command on the file and returns the text it produces.
+func (c CommandConverter) Convert(ctx context.Context, filename string) (string, error) {
+	if len(c.Command) == 0 {
+		return "", fmt.Errorf("no converter command for %s", filename)
+	}
+	tempdir, err := os.MkdirTemp("", "vdb")
+	if err != nil {
+		return "", fmt.Errorf("unable to create a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	outputFile := filepath.Join(tempdir, "output.txt")
+	usesOutput := false
+	args := make([]string, len(c.Command))
+	for i, arg := range c.Command {
+		if strings.Contains(arg, "{output}") {
+			usesOutput = true
+		}
+		arg = strings.ReplaceAll(arg, "{input}", filename)
+		args[i] = strings.ReplaceAll(arg, "{output}", outputFile)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("cannot convert %s: %w: %s", filename, err, strings.TrimSpace(stderr.String()))
+	}
+	if !usesOutput {
+		return stdout.String(), nil
+	}
+
+	text, err := os.ReadFile(outputFile)
+	if err != nil {
+		return "", fmt.Errorf("cannot read text: %w", err)
+	}
+	return string(text), nil
+}
+
+// ConverterConfig configures an external converter, either a command or a
+// Go plugin.
+type ConverterConfig struct {
+	// Command is the command to run, as for CommandConverter.
+	Command []string `json:"command,omitempty"`
+	// Plugin is the path of a Go plugin exporting a Convert function with
+	// the signature func(context.Context, string) (string, error). It is
+	// loaded with PluginLoader.
+	Plugin string `json:"plugin,omitempty"`
+}
+
+// PluginLoader opens the Go plugin at path and returns its converter, for
+// converters configured with a Plugin. vdb does not load plugins itself so
+// that programs using it can be built statically; if PluginLoader is nil,
+// configuring a plugin is an error.
+var PluginLoader func(path string) (Converter, error)
+
+// LoadConverters reads a JSON file mapping file extensions to converter
+// configurations and registers the converters, for example:
+//
+//	{
+//		".rtf": {"command": ["unrtf", "--text", "{input}"]},
+//		".docx": {"plugin": "plugins/docx.so"}
+//	}
+func LoadConverters(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var configs map[string]ConverterConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+
+	for ext, config := range configs {
+		converter, err := config.converter()
+		if err != nil {
+			return fmt.Errorf("converter for %s: %w", ext, err)
+		}
+		RegisterConverter(ext, converter)
+	}
+	return nil
+}
+
+func (config ConverterConfig) converter() (Converter, error) {
+	switch {
+	case len(config.Command) > 0 && config.Plugin != "":
+		return nil, errors.New("both command and plugin given")
+	case len(config.Command) > 0:
+		return CommandConverter{Command: config.Command}, nil
+	case config.Plugin != "" && PluginLoader == nil:
+		return nil, errors.New("plugins are not supported")
+	case config.Plugin != "":
+		return PluginLoader(config.Plugin)
+	}
+	return nil, errors.New("no command or plugin given")
+}
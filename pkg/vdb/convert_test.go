@@ -0,0 +1,43 @@
+package vdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConvertersUsesPluginLoader(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "converters.json")
+	if err := os.WriteFile(config, []byte(`{".plug": {"plugin": "plugins/plug.so"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(loader func(string) (Converter, error)) { PluginLoader = loader }(PluginLoader)
+
+	PluginLoader = nil
+	if err := LoadConverters(config); err == nil {
+		t.Error("loaded a plugin converter without a PluginLoader")
+	}
+
+	var loaded string
+	PluginLoader = func(path string) (Converter, error) {
+		loaded = path
+		return ConverterFunc(func(ctx context.Context, filename string) (string, error) {
+			return "converted by the plugin", nil
+		}), nil
+	}
+	if err := LoadConverters(config); err != nil {
+		t.Fatal(err)
+	}
+	if loaded != "plugins/plug.so" {
+		t.Errorf("loaded plugin %q, want plugins/plug.so", loaded)
+	}
+	text, err := Convert(context.Background(), filepath.Join(dir, "notes.plug"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "converted by the plugin" {
+		t.Errorf("converted to %q, want the plugin's text", text)
+	}
+}
@@ -0,0 +1,84 @@
+package vdb
+
+// DefaultDuplicateThreshold is the similarity above which FindDuplicates
+// treats two chunks as near-duplicates.
+const DefaultDuplicateThreshold = 0.95
+
+// Duplicate is a chunk that is a near-duplicate of a chunk added to the
+// store before it.
+type Duplicate struct {
+	Chunk Chunk
+	// Of is the earlier chunk it duplicates.
+	Of    Chunk
+	Score float32
+}
+
+// DocumentDuplicates describes how much of a document duplicates content
+// stored before it.
+type DocumentDuplicates struct {
+	Name       string `json:"name"`
+	Chunks     int    `json:"chunks"`
+	Duplicates int    `json:"duplicates"`
+	// Of lists the documents the duplicated content was first stored in.
+	Of []string `json:"of"`
+}
+
+// FindDuplicates compares every pair of chunks in the store and returns
+// the chunks whose similarity to an earlier chunk is at least threshold,
+// in the order they were added. Each is paired with the most similar
+// earlier chunk that is not itself a duplicate. Chunk IDs are unique in a
+// store, so removing all the returned chunks by ID keeps one copy of every
+// piece of content.
+func (s *Store) FindDuplicates(threshold float32) []Duplicate {
+	chunks := s.Chunks()
+	magnitudes := make([]float32, len(chunks))
+	for i, chunk := range chunks {
+		magnitudes[i] = magnitude(chunk.Embedding)
+	}
+
+	var duplicates []Duplicate
+	var kept []int
+	for i, chunk := range chunks {
+		best, bestScore := -1, threshold
+		for _, j := range kept {
+			score := dotproduct(chunk.Embedding, chunks[j].Embedding) / (magnitudes[i] * magnitudes[j])
+			if score >= bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best < 0 {
+			kept = append(kept, i)
+			continue
+		}
+		duplicates = append(duplicates, Duplicate{Chunk: chunk, Of: chunks[best], Score: bestScore})
+	}
+	return duplicates
+}
+
+// DuplicateDocuments summarizes the duplicates by document, listing the
+// documents with duplicated chunks in the order they were added. A
+// document whose Duplicates equals its Chunks is entirely redundant.
+func (s *Store) DuplicateDocuments(duplicates []Duplicate) []DocumentDuplicates {
+	bySource := map[string][]Duplicate{}
+	for _, d := range duplicates {
+		bySource[d.Chunk.Source] = append(bySource[d.Chunk.Source], d)
+	}
+
+	var documents []DocumentDuplicates
+	for _, info := range s.Documents() {
+		dups := bySource[info.Name]
+		if len(dups) == 0 {
+			continue
+		}
+		document := DocumentDuplicates{Name: info.Name, Chunks: info.Chunks, Duplicates: len(dups)}
+		seen := map[string]bool{}
+		for _, d := range dups {
+			if !seen[d.Of.Source] {
+				seen[d.Of.Source] = true
+				document.Of = append(document.Of, d.Of.Source)
+			}
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
@@ -0,0 +1,83 @@
+package vdb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAddReplacesChunksWithTheSameID(t *testing.T) {
+	store := NewStore("")
+	a := NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0})
+	b := NewChunk("handbook.pdf", "expenses are approved by managers", []float32{0, 1})
+	for i := 0; i < 2; i++ {
+		if err := store.Add(a, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.Len() != 2 {
+		t.Errorf("store has %d chunks after adding a document twice, want 2", store.Len())
+	}
+}
+
+func TestLoadCollapsesDuplicateChunks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vdb.gob")
+	a := NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0})
+	b := NewChunk("handbook.pdf", "expenses are approved by managers", []float32{0, 1})
+	// as written by versions of vdb that appended re-added documents
+	if err := writeGob(path, storeFile{Chunks: []Chunk{a, b, a, b}}); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(path)
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 2 {
+		t.Errorf("loaded %d chunks, want 2", store.Len())
+	}
+}
+
+func TestPruningDuplicatesKeepsOneCopy(t *testing.T) {
+	store := NewStore("")
+	chunks := []Chunk{
+		NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0, 0}),
+		NewChunk("handbook.pdf", "expenses are approved by managers", []float32{0, 1, 0}),
+		// the same document added again
+		NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0, 0}),
+		NewChunk("handbook.pdf", "expenses are approved by managers", []float32{0, 1, 0}),
+		// the same content in another document, and a near copy
+		NewChunk("handbook-copy.pdf", "annual leave is 18 days", []float32{1, 0, 0}),
+		NewChunk("faq.pdf", "annual leave is 18 days.", []float32{0.99, 0.01, 0}),
+		NewChunk("faq.pdf", "the office opens at 9", []float32{0, 0, 1}),
+	}
+	if err := store.Add(chunks...); err != nil {
+		t.Fatal(err)
+	}
+
+	duplicates := store.FindDuplicates(DefaultDuplicateThreshold)
+	if len(duplicates) != 2 {
+		t.Fatalf("found %d duplicates, want 2", len(duplicates))
+	}
+	for _, d := range duplicates {
+		if d.Of.ID != chunks[0].ID {
+			t.Errorf("%s duplicates %s, want %s", d.Chunk.ID, d.Of.ID, chunks[0].ID)
+		}
+	}
+
+	var ids []string
+	for _, d := range duplicates {
+		ids = append(ids, d.Chunk.ID)
+	}
+	if removed := store.Remove(ids...); removed != 2 {
+		t.Errorf("removed %d chunks, want 2", removed)
+	}
+	want := []string{chunks[0].ID, chunks[1].ID, chunks[6].ID}
+	got := store.Chunks()
+	if len(got) != len(want) {
+		t.Fatalf("%d chunks left, want %d", len(got), len(want))
+	}
+	for i, chunk := range got {
+		if chunk.ID != want[i] {
+			t.Errorf("chunk %d is %s, want %s", i, chunk.ID, want[i])
+		}
+	}
+}
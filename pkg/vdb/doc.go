@@ -0,0 +1,31 @@
+// Package vdb is a small in-memory vector database for retrieval augmented
+// generation (RAG) with Ollama.
+//
+// Documents are converted to text, split into chunks and embedded, and the
+// chunks are kept in a Store that is saved to a gob file. A Retriever finds
+// the chunks most similar to a question, and a Client ties it together,
+// answering questions using the retrieved chunks as context:
+//
+//	store, err := vdb.Open("vdb.gob")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client := vdb.NewClient(store)
+//	if _, err := client.Ingest(ctx, "handbook.pdf"); err != nil {
+//		log.Fatal(err)
+//	}
+//	answer, err := client.Ask(ctx, "How many days of leave do I get?", nil, nil)
+//
+// Ingestion can be customised with a Pipeline, which runs a document
+// through replaceable convert, chunk, embed and store stages, with hooks
+// for cleaning documents or enriching chunks with metadata.
+//
+// Chunks and questions are embedded by an Embedder, which is Ollama unless
+// another is given with WithEmbedder.
+//
+// Store also implements langchaingo's vectorstores.VectorStore, so it can be
+// used as a local vector store in langchaingo chains and retrievers.
+//
+// The Ollama server must be running for embedding and generation.
+// WaitForOllama waits for a server that is still starting up.
+package vdb
@@ -0,0 +1,61 @@
+package vdb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms/ollama"
+)
+
+// Embedder turns texts into embeddings, one for each text in the same
+// order. Implementations can use any embedding backend; OllamaEmbedder is
+// the one vdb uses by default.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc is an adapter to allow the use of ordinary functions as
+// Embedders.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Embed calls f(ctx, texts).
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
+
+// OllamaEmbedder gets embeddings from an Ollama embedding model. The
+// connection to Ollama is made on the first Embed and reused after that, so
+// Model should not be changed once the embedder is in use.
+type OllamaEmbedder struct {
+	Model string
+	llm   ollamaLLM
+}
+
+// NewOllamaEmbedder returns an embedder using the given Ollama model.
+func NewOllamaEmbedder(model string) *OllamaEmbedder {
+	return &OllamaEmbedder{Model: model}
+}
+
+// Embed gets embeddings for the texts from Ollama.
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	llm, err := e.llm.get(e.Model)
+	if err != nil {
+		return nil, err
+	}
+	return llm.CreateEmbedding(ctx, texts)
+}
+
+// an Ollama model created on first use and shared after that, so requests
+// reuse the same HTTP client and its idle connections
+type ollamaLLM struct {
+	once sync.Once
+	llm  *ollama.LLM
+	err  error
+}
+
+func (l *ollamaLLM) get(model string) (*ollama.LLM, error) {
+	l.once.Do(func() {
+		l.llm, l.err = ollama.New(ollama.WithModel(model))
+	})
+	return l.llm, l.err
+}
@@ -0,0 +1,44 @@
+package vdb
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrStoreNotFound is returned when there is no saved store at a path.
+	ErrStoreNotFound = errors.New("store not found")
+	// ErrDimensionMismatch is returned when an embedding does not have the
+	// same number of dimensions as those already in the store. The error is
+	// a *DimensionError.
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+	// ErrEmbeddingFailed is returned when the embedding model cannot embed
+	// chunks or a question.
+	ErrEmbeddingFailed = errors.New("embedding failed")
+	// ErrUnsupportedFormat is returned when a document cannot be converted
+	// because there is no converter for its format.
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	// ErrOllamaUnavailable is returned by WaitForOllama when the Ollama
+	// server does not become ready in time.
+	ErrOllamaUnavailable = errors.New("ollama unavailable")
+	// ErrSnapshotNotFound is returned when there is no snapshot of a store
+	// with a given name.
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+// DimensionError reports an embedding with a different number of dimensions
+// from the embeddings in the store, usually because the store was built with
+// a different embedding model.
+type DimensionError struct {
+	Want int
+	Got  int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("%v: store has %d dimensions, got %d", ErrDimensionMismatch, e.Want, e.Got)
+}
+
+// Is makes errors.Is(err, ErrDimensionMismatch) true for a *DimensionError.
+func (e *DimensionError) Is(target error) bool {
+	return target == ErrDimensionMismatch
+}
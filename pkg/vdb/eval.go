@@ -0,0 +1,209 @@
+package vdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// EvalCase is a question and the chunks that should be retrieved for it,
+// given as chunk IDs, source documents or both. A retrieved chunk is
+// relevant if its ID is one of Chunks or its source is one of Sources.
+type EvalCase struct {
+	Question string   `json:"question"`
+	Sources  []string `json:"sources,omitempty"`
+	Chunks   []string `json:"chunks,omitempty"`
+}
+
+// UnmarshalJSON also accepts a single expected "source" or "chunk".
+func (c *EvalCase) UnmarshalJSON(data []byte) error {
+	type evalCase EvalCase
+	var v struct {
+		evalCase
+		Source string `json:"source"`
+		Chunk  string `json:"chunk"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = EvalCase(v.evalCase)
+	if v.Source != "" {
+		c.Sources = append(c.Sources, v.Source)
+	}
+	if v.Chunk != "" {
+		c.Chunks = append(c.Chunks, v.Chunk)
+	}
+	return nil
+}
+
+// ReadEvalCases reads evaluation cases from JSON lines, for example:
+//
+//	{"question": "How many days of leave do I get?", "source": "handbook.pdf"}
+//	{"question": "Who approves expenses?", "chunks": ["3f2a9c0d1e4b5a6c"]}
+func ReadEvalCases(r io.Reader) ([]EvalCase, error) {
+	var cases []EvalCase
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var c EvalCase
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if c.Question == "" || (len(c.Sources) == 0 && len(c.Chunks) == 0) {
+			return nil, fmt.Errorf("line %d: need a question and an expected source or chunk", line)
+		}
+		cases = append(cases, c)
+	}
+	return cases, scanner.Err()
+}
+
+// EvalResult holds retrieval metrics over a set of evaluation cases for the
+// top K retrieved chunks, averaged over the cases.
+type EvalResult struct {
+	K     int `json:"k"`
+	Cases int `json:"cases"`
+	// Recall is the fraction of expected chunks and sources found.
+	Recall float64 `json:"recall"`
+	// MRR is the mean reciprocal rank of the first relevant chunk.
+	MRR float64 `json:"mrr"`
+	// NDCG is the normalized discounted cumulative gain, with binary
+	// relevance.
+	NDCG float64 `json:"ndcg"`
+}
+
+// Evaluate runs each case's question through the retriever and measures
+// the retrieval for each of the ks. The retriever's own K is ignored.
+func Evaluate(ctx context.Context, r *Retriever, cases []EvalCase, ks []int) ([]EvalResult, error) {
+	if len(ks) == 0 {
+		return nil, errors.New("no k to evaluate")
+	}
+	ks = append([]int(nil), ks...)
+	sort.Ints(ks)
+	retriever := *r
+	retriever.K = ks[len(ks)-1]
+
+	results := make([]EvalResult, len(ks))
+	for i, k := range ks {
+		results[i] = EvalResult{K: k, Cases: len(cases)}
+	}
+	stored := r.Store.Chunks()
+
+	for _, c := range cases {
+		retrieved, err := retriever.Retrieve(ctx, c.Question)
+		if err != nil {
+			return nil, fmt.Errorf("cannot retrieve chunks for %q: %w", c.Question, err)
+		}
+		relevant := relevance(c, retrieved)
+
+		// the number of chunks in the store that are relevant, for the
+		// ideal ranking
+		available := 0
+		for _, chunk := range stored {
+			if c.isRelevant(chunk) {
+				available++
+			}
+		}
+
+		for i, k := range ks {
+			top := relevant[:min(k, len(relevant))]
+			results[i].Recall += recall(c, retrieved[:len(top)])
+			results[i].MRR += reciprocalRank(top)
+			results[i].NDCG += ndcg(top, min(k, available))
+		}
+	}
+
+	if n := float64(len(cases)); n > 0 {
+		for i := range results {
+			results[i].Recall /= n
+			results[i].MRR /= n
+			results[i].NDCG /= n
+		}
+	}
+	return results, nil
+}
+
+func (c EvalCase) isRelevant(chunk Chunk) bool {
+	for _, id := range c.Chunks {
+		if chunk.ID == id {
+			return true
+		}
+	}
+	for _, source := range c.Sources {
+		if chunk.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// whether each of the retrieved chunks is relevant to the case
+func relevance(c EvalCase, retrieved []ScoredChunk) []bool {
+	relevant := make([]bool, len(retrieved))
+	for i, chunk := range retrieved {
+		relevant[i] = c.isRelevant(chunk.Chunk)
+	}
+	return relevant
+}
+
+// the fraction of the case's expected chunks and sources that were retrieved
+func recall(c EvalCase, retrieved []ScoredChunk) float64 {
+	expected := len(c.Chunks) + len(c.Sources)
+	if expected == 0 {
+		return 0
+	}
+	found := 0
+	for _, id := range c.Chunks {
+		for _, chunk := range retrieved {
+			if chunk.ID == id {
+				found++
+				break
+			}
+		}
+	}
+	for _, source := range c.Sources {
+		for _, chunk := range retrieved {
+			if chunk.Source == source {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(expected)
+}
+
+func reciprocalRank(relevant []bool) float64 {
+	for i, r := range relevant {
+		if r {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// normalized DCG of the ranking, where ideal is the number of relevant
+// chunks an ideal ranking of the same length would have
+func ndcg(relevant []bool, ideal int) float64 {
+	var dcg, idcg float64
+	for i, r := range relevant {
+		if r {
+			dcg += 1 / math.Log2(float64(i+2))
+		}
+	}
+	for i := 0; i < ideal; i++ {
+		idcg += 1 / math.Log2(float64(i+2))
+	}
+	if idcg == 0 {
+		return 0
+	}
+	return dcg / idcg
+}
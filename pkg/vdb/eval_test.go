@@ -0,0 +1,151 @@
+package vdb
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+const tolerance = 1e-4
+
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) < tolerance
+}
+
+func TestRecall(t *testing.T) {
+	retrieved := []ScoredChunk{
+		{Chunk: Chunk{ID: "c1", Source: "a.pdf"}},
+		{Chunk: Chunk{ID: "c2", Source: "b.pdf"}},
+	}
+	tests := []struct {
+		name string
+		c    EvalCase
+		want float64
+	}{
+		{"source found", EvalCase{Sources: []string{"b.pdf"}}, 1},
+		{"chunk and source found", EvalCase{Chunks: []string{"c1"}, Sources: []string{"b.pdf"}}, 1},
+		{"one of two chunks found", EvalCase{Chunks: []string{"c2", "c9"}}, 0.5},
+		{"one of three found", EvalCase{Chunks: []string{"c9"}, Sources: []string{"a.pdf", "z.pdf"}}, 1.0 / 3},
+		{"nothing found", EvalCase{Sources: []string{"z.pdf"}}, 0},
+		{"nothing expected", EvalCase{}, 0},
+	}
+	for _, tt := range tests {
+		if got := recall(tt.c, retrieved); !closeTo(got, tt.want) {
+			t.Errorf("%s: recall = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReciprocalRank(t *testing.T) {
+	tests := []struct {
+		relevant []bool
+		want     float64
+	}{
+		{[]bool{true, false, false}, 1},
+		{[]bool{false, true, true}, 0.5},
+		{[]bool{false, false, false, true}, 0.25},
+		{[]bool{false, false}, 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := reciprocalRank(tt.relevant); !closeTo(got, tt.want) {
+			t.Errorf("reciprocalRank(%v) = %v, want %v", tt.relevant, got, tt.want)
+		}
+	}
+}
+
+func TestNDCG(t *testing.T) {
+	tests := []struct {
+		relevant []bool
+		ideal    int
+		want     float64
+	}{
+		// the only relevant chunk ranked first
+		{[]bool{true, false, false}, 1, 1},
+		// ranked second: (1/log2(3)) / 1
+		{[]bool{false, true, false}, 1, 0.6309},
+		// first and third of two: (1 + 1/log2(4)) / (1 + 1/log2(3))
+		{[]bool{true, false, true}, 2, 0.9197},
+		// one of two relevant chunks found, first: 1 / (1 + 1/log2(3))
+		{[]bool{true, false, false}, 2, 0.6131},
+		{[]bool{false, false, false}, 1, 0},
+		{[]bool{false, false}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := ndcg(tt.relevant, tt.ideal); !closeTo(got, tt.want) {
+			t.Errorf("ndcg(%v, %d) = %v, want %v", tt.relevant, tt.ideal, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	store := NewStore("")
+	chunks := []Chunk{
+		NewChunk("a.pdf", "first", []float32{1, 0}),
+		NewChunk("b.pdf", "second", []float32{0.8, 0.6}),
+		NewChunk("c.pdf", "third", []float32{0.6, 0.8}),
+		NewChunk("d.pdf", "fourth", []float32{0, 1}),
+	}
+	if err := store.Add(chunks...); err != nil {
+		t.Fatal(err)
+	}
+	// every question retrieves the chunks in the order they were added
+	embedder := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		embeddings := make([][]float32, len(texts))
+		for i := range texts {
+			embeddings[i] = []float32{1, 0}
+		}
+		return embeddings, nil
+	})
+	cases := []EvalCase{
+		// relevant chunk ranked second
+		{Question: "partial", Sources: []string{"b.pdf"}},
+		// relevant chunks ranked first and third
+		{Question: "two chunks", Chunks: []string{chunks[0].ID, chunks[2].ID}},
+		// nothing relevant in the store
+		{Question: "no match", Sources: []string{"z.pdf"}},
+	}
+
+	results, err := Evaluate(context.Background(), &Retriever{Store: store, Embedder: embedder}, cases, []int{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []EvalResult{
+		{K: 1, Cases: 3, Recall: (0 + 0.5 + 0) / 3, MRR: (0 + 1 + 0) / 3.0, NDCG: (0 + 1 + 0) / 3.0},
+		{K: 3, Cases: 3, Recall: (1 + 1 + 0) / 3.0, MRR: (0.5 + 1 + 0) / 3, NDCG: (0.63093 + 0.91972 + 0) / 3},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, got := range results {
+		w := want[i]
+		if got.K != w.K || got.Cases != w.Cases || !closeTo(got.Recall, w.Recall) || !closeTo(got.MRR, w.MRR) || !closeTo(got.NDCG, w.NDCG) {
+			t.Errorf("result %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestReadEvalCases(t *testing.T) {
+	input := `{"question": "How many days of leave?", "source": "handbook.pdf"}
+
+{"question": "Who approves expenses?", "chunks": ["3f2a9c0d1e4b5a6c"], "sources": ["policy.pdf"]}
+`
+	cases, err := ReadEvalCases(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 {
+		t.Fatalf("read %d cases, want 2", len(cases))
+	}
+	if len(cases[0].Sources) != 1 || cases[0].Sources[0] != "handbook.pdf" {
+		t.Errorf("case 1 sources = %v, want [handbook.pdf]", cases[0].Sources)
+	}
+	if len(cases[1].Chunks) != 1 || len(cases[1].Sources) != 1 {
+		t.Errorf("case 2 = %+v, want one chunk and one source", cases[1])
+	}
+
+	if _, err := ReadEvalCases(strings.NewReader(`{"question": "nothing expected"}`)); err == nil {
+		t.Error("a case with no expected source or chunk was accepted")
+	}
+}
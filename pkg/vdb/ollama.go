@@ -0,0 +1,52 @@
+package vdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// how long WaitForOllama waits between checks, at most
+const maxReadinessInterval = time.Second
+
+// WaitForOllama waits until the Ollama server at baseURL, such as
+// http://127.0.0.1:11434, answers requests, checking at growing intervals.
+// If ctx expires first, the error wraps ErrOllamaUnavailable and the last
+// check's failure.
+func WaitForOllama(ctx context.Context, baseURL string) error {
+	interval := 50 * time.Millisecond
+	for {
+		err := checkOllama(ctx, baseURL)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w at %s: %w", ErrOllamaUnavailable, baseURL, err)
+		case <-time.After(interval):
+		}
+		interval = min(2*interval, maxReadinessInterval)
+	}
+}
+
+// checks once whether the Ollama server is answering
+func checkOllama(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package vdb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// Document is a source document on its way through a Pipeline.
+type Document struct {
+	// Source is the name chunks of the document are stored under.
+	Source string
+	// Path is the file the document was read from, if any.
+	Path string
+	// Text is the document's text, set by the converter.
+	Text string
+	// Metadata is copied to every chunk of the document.
+	Metadata map[string]string
+}
+
+// Chunker splits a document's text into the texts of its chunks.
+type Chunker interface {
+	Chunk(text string) []string
+}
+
+// ChunkerFunc is an adapter to allow the use of ordinary functions as
+// Chunkers.
+type ChunkerFunc func(text string) []string
+
+// Chunk calls f(text).
+func (f ChunkerFunc) Chunk(text string) []string {
+	return f(text)
+}
+
+// DocumentHook is called with each document after it is converted and
+// before it is chunked. It can change the document's text, for example to
+// clean it up, or add metadata. Returning an error stops the ingestion.
+type DocumentHook func(ctx context.Context, doc *Document) error
+
+// ChunkHook is called with each chunk before it is embedded. It can change
+// the chunk's content or metadata, or return false to drop the chunk.
+// Returning an error stops the ingestion.
+type ChunkHook func(ctx context.Context, chunk *Chunk) (bool, error)
+
+// Pipeline ingests documents into a store in stages: a converter turns a
+// file into a Document, document hooks transform it, a chunker splits it
+// into chunks, chunk hooks transform or drop them, an embedder embeds them
+// and they are added to the store, which is then saved. Each stage can be
+// replaced:
+//
+//	pipeline := vdb.NewPipeline(store, vdb.NewOllamaEmbedder(vdb.DefaultEmbeddingModel)).
+//		WithChunker(vdb.ChunkerFunc(splitSentences)).
+//		OnDocument(removeHeaders).
+//		OnChunk(tagChapter)
+//	ids, err := pipeline.Run(ctx, "handbook.pdf")
+type Pipeline struct {
+	store      *Store
+	embedder   Embedder
+	converter  Converter
+	chunker    Chunker
+	docHooks   []DocumentHook
+	chunkHooks []ChunkHook
+	timeouts   Timeouts
+}
+
+// NewPipeline returns a pipeline that embeds chunks with the embedder and
+// adds them to the store, converting files with Convert and chunking them
+// with Split.
+func NewPipeline(store *Store, embedder Embedder) *Pipeline {
+	return &Pipeline{
+		store:     store,
+		embedder:  embedder,
+		converter: ConverterFunc(Convert),
+		chunker:   ChunkerFunc(Split),
+	}
+}
+
+// WithConverter replaces the converter that turns files into text.
+func (p *Pipeline) WithConverter(converter Converter) *Pipeline {
+	p.converter = converter
+	return p
+}
+
+// WithChunker replaces the chunker that splits text into chunks.
+func (p *Pipeline) WithChunker(chunker Chunker) *Pipeline {
+	p.chunker = chunker
+	return p
+}
+
+// WithTimeouts sets the timeouts for the convert and embed stages.
+func (p *Pipeline) WithTimeouts(timeouts Timeouts) *Pipeline {
+	p.timeouts = timeouts
+	return p
+}
+
+// OnDocument adds a hook that is called with each converted document.
+// Hooks are called in the order they are added.
+func (p *Pipeline) OnDocument(hook DocumentHook) *Pipeline {
+	p.docHooks = append(p.docHooks, hook)
+	return p
+}
+
+// OnChunk adds a hook that is called with each chunk before it is embedded.
+// Hooks are called in the order they are added.
+func (p *Pipeline) OnChunk(hook ChunkHook) *Pipeline {
+	p.chunkHooks = append(p.chunkHooks, hook)
+	return p
+}
+
+// Run converts each file and ingests it under its base name, returning the
+// IDs of all the added chunks.
+func (p *Pipeline) Run(ctx context.Context, filenames ...string) ([]string, error) {
+	var ids []string
+	for _, filename := range filenames {
+		doc, err := p.Convert(ctx, filename)
+		if err != nil {
+			return ids, err
+		}
+		added, err := p.Ingest(ctx, doc)
+		ids = append(ids, added...)
+		if err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+// Convert runs the convert stage, reading the file into a Document.
+func (p *Pipeline) Convert(ctx context.Context, filename string) (*Document, error) {
+	ctx, cancel := withTimeout(ctx, p.timeouts.Convert)
+	defer cancel()
+	text, err := p.converter.Convert(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		Source: filepath.Base(filename),
+		Path:   filename,
+		Text:   text,
+	}, nil
+}
+
+// Ingest runs an already converted document through the rest of the
+// pipeline, returning the IDs of the added chunks.
+func (p *Pipeline) Ingest(ctx context.Context, doc *Document) ([]string, error) {
+	for _, hook := range p.docHooks {
+		if err := hook(ctx, doc); err != nil {
+			return nil, fmt.Errorf("document hook for %s: %w", doc.Source, err)
+		}
+	}
+	chunks, err := p.Chunk(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return p.Store(ctx, chunks)
+}
+
+// Chunk runs the chunk stage and the chunk hooks on the document, returning
+// the chunks to embed.
+func (p *Pipeline) Chunk(ctx context.Context, doc *Document) ([]Chunk, error) {
+	var chunks []Chunk
+	for _, text := range p.chunker.Chunk(doc.Text) {
+		chunk := NewChunk(doc.Source, text, nil)
+		if len(doc.Metadata) > 0 {
+			chunk.Metadata = make(map[string]string, len(doc.Metadata))
+			for k, v := range doc.Metadata {
+				chunk.Metadata[k] = v
+			}
+		}
+
+		keep := true
+		var err error
+		for _, hook := range p.chunkHooks {
+			keep, err = hook(ctx, &chunk)
+			if err != nil {
+				return nil, fmt.Errorf("chunk hook for %s: %w", doc.Source, err)
+			}
+			if !keep {
+				break
+			}
+		}
+		if keep {
+			// hooks may have changed the content the ID is derived from
+			chunk.ID = chunkID(chunk.Source, chunk.Content)
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+// Store runs the embed stage on the chunks and adds them to the store,
+// then saves the store, returning the IDs of the added chunks.
+func (p *Pipeline) Store(ctx context.Context, chunks []Chunk) ([]string, error) {
+	if len(chunks) == 0 {
+		return []string{}, nil
+	}
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Content
+	}
+
+	ctx, cancel := withTimeout(ctx, p.timeouts.Embed)
+	defer cancel()
+	embeddings, err := p.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	if len(embeddings) != len(chunks) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d chunks", ErrEmbeddingFailed, len(embeddings), len(chunks))
+	}
+
+	ids := make([]string, len(chunks))
+	for i := range chunks {
+		chunks[i].Embedding = embeddings[i]
+		ids[i] = chunks[i].ID
+	}
+	if err := p.store.Add(chunks...); err != nil {
+		return nil, err
+	}
+	if ollama, ok := p.embedder.(*OllamaEmbedder); ok && p.store.EmbeddingModel() == "" {
+		p.store.SetEmbeddingModel(ollama.Model)
+	}
+	if err := p.store.Save(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
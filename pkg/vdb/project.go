@@ -0,0 +1,321 @@
+package vdb
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Point is a chunk projected to two dimensions for visualization.
+type Point struct {
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	ID      string  `json:"id"`
+	Source  string  `json:"source"`
+	Content string  `json:"content"`
+}
+
+// the number of power iterations used to find each principal component
+const pcaIterations = 100
+
+// ProjectPCA projects the chunks' embeddings onto their first two principal
+// components.
+func ProjectPCA(chunks []Chunk) []Point {
+	points := newPoints(chunks)
+	if len(chunks) == 0 {
+		return points
+	}
+	coordinates := pca(embeddingMatrix(chunks), 2, rand.New(rand.NewSource(1)))
+	for i := range points {
+		points[i].X, points[i].Y = coordinates[i][0], coordinates[i][1]
+	}
+	return points
+}
+
+// UMAPOptions configures ProjectUMAP.
+type UMAPOptions struct {
+	// Neighbors is the number of nearest neighbors each chunk's
+	// neighborhood is made of. Larger values preserve more of the global
+	// structure. If it is zero, 15 is used.
+	Neighbors int
+	// Epochs is the number of optimization passes. If it is zero, 200 is
+	// used.
+	Epochs int
+	// Seed seeds the random sampling.
+	Seed int64
+}
+
+// ProjectUMAP projects the chunks' embeddings into two dimensions with a
+// simplified UMAP: a fuzzy graph of each chunk's nearest neighbors by cosine
+// distance is laid out in the plane by stochastic gradient descent, starting
+// from the PCA projection. Chunks that are close in the graph end up close
+// together, so clusters and outliers stand out more than with PCA.
+func ProjectUMAP(chunks []Chunk, opts UMAPOptions) []Point {
+	if len(chunks) < 3 {
+		return ProjectPCA(chunks)
+	}
+	neighbors := opts.Neighbors
+	if neighbors <= 0 {
+		neighbors = 15
+	}
+	neighbors = min(neighbors, len(chunks)-1)
+	epochs := opts.Epochs
+	if epochs <= 0 {
+		epochs = 200
+	}
+	random := rand.New(rand.NewSource(opts.Seed))
+
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		vectors[i] = normalize(chunk.Embedding)
+	}
+	edges := fuzzyGraph(vectors, neighbors)
+
+	// start from PCA, scaled to a spread the optimization works well with
+	layout := pca(embeddingMatrix(chunks), 2, random)
+	scaleLayout(layout, 10)
+	optimizeLayout(layout, edges, epochs, random)
+
+	points := newPoints(chunks)
+	for i := range points {
+		points[i].X, points[i].Y = layout[i][0], layout[i][1]
+	}
+	return points
+}
+
+func newPoints(chunks []Chunk) []Point {
+	points := make([]Point, len(chunks))
+	for i, chunk := range chunks {
+		points[i] = Point{ID: chunk.ID, Source: chunk.Source, Content: chunk.Content}
+	}
+	return points
+}
+
+// the chunks' embeddings as the rows of a float64 matrix
+func embeddingMatrix(chunks []Chunk) [][]float64 {
+	data := make([][]float64, len(chunks))
+	for i, chunk := range chunks {
+		data[i] = make([]float64, len(chunk.Embedding))
+		for j, v := range chunk.Embedding {
+			data[i][j] = float64(v)
+		}
+	}
+	return data
+}
+
+// projects the rows of data onto their first n principal components. The
+// components are found by power iteration on the covariance, which is
+// applied through the centered data rather than built, so the cost is
+// linear in the number of dimensions
+func pca(data [][]float64, n int, random *rand.Rand) [][]float64 {
+	dimension := len(data[0])
+	mean := make([]float64, dimension)
+	for _, row := range data {
+		for j, v := range row {
+			mean[j] += v / float64(len(data))
+		}
+	}
+	centered := make([][]float64, len(data))
+	for i, row := range data {
+		centered[i] = make([]float64, dimension)
+		for j, v := range row {
+			centered[i][j] = v - mean[j]
+		}
+	}
+
+	projected := make([][]float64, len(data))
+	for i := range projected {
+		projected[i] = make([]float64, n)
+	}
+	var components [][]float64
+	for c := 0; c < n; c++ {
+		v := make([]float64, dimension)
+		for j := range v {
+			v[j] = random.Float64() - 0.5
+		}
+		for iteration := 0; iteration < pcaIterations; iteration++ {
+			// v = covariance * v, with earlier components removed
+			next := make([]float64, dimension)
+			for _, row := range centered {
+				dot := dot64(row, v)
+				for j := range next {
+					next[j] += dot * row[j]
+				}
+			}
+			for _, component := range components {
+				dot := dot64(next, component)
+				for j := range next {
+					next[j] -= dot * component[j]
+				}
+			}
+			norm := math.Sqrt(dot64(next, next))
+			if norm == 0 {
+				break
+			}
+			for j := range next {
+				next[j] /= norm
+			}
+			v = next
+		}
+		components = append(components, v)
+		for i, row := range centered {
+			projected[i][c] = dot64(row, v)
+		}
+	}
+	return projected
+}
+
+func dot64(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// scales the layout so its largest coordinate is size
+func scaleLayout(layout [][]float64, size float64) {
+	var largest float64
+	for _, p := range layout {
+		largest = math.Max(largest, math.Max(math.Abs(p[0]), math.Abs(p[1])))
+	}
+	if largest == 0 {
+		return
+	}
+	for _, p := range layout {
+		p[0] *= size / largest
+		p[1] *= size / largest
+	}
+}
+
+// an edge of the fuzzy neighbor graph, with the probability that the two
+// chunks are neighbors
+type edge struct {
+	from, to int
+	weight   float64
+}
+
+// builds UMAP's fuzzy simplicial set: each chunk's distances to its nearest
+// neighbors are turned into membership strengths, calibrated so they sum to
+// log2(neighbors), and the directed memberships are combined by fuzzy union
+func fuzzyGraph(vectors [][]float32, neighbors int) []edge {
+	type neighbor struct {
+		index    int
+		distance float64
+	}
+	weights := map[[2]int]float64{}
+	for i, v := range vectors {
+		nearest := make([]neighbor, 0, len(vectors)-1)
+		for j, w := range vectors {
+			if i != j {
+				nearest = append(nearest, neighbor{j, distance(v, w)})
+			}
+		}
+		sort.Slice(nearest, func(a, b int) bool {
+			return nearest[a].distance < nearest[b].distance
+		})
+		nearest = nearest[:neighbors]
+
+		rho := nearest[0].distance
+		target := math.Log2(float64(neighbors))
+		// binary search for the bandwidth giving the target total membership
+		low, high, sigma := 0.0, math.Inf(1), 1.0
+		for iteration := 0; iteration < 64; iteration++ {
+			var sum float64
+			for _, n := range nearest {
+				sum += math.Exp(-math.Max(0, n.distance-rho) / sigma)
+			}
+			if math.Abs(sum-target) < 1e-5 {
+				break
+			}
+			if sum > target {
+				high = sigma
+				sigma = (low + high) / 2
+			} else {
+				low = sigma
+				if math.IsInf(high, 1) {
+					sigma *= 2
+				} else {
+					sigma = (low + high) / 2
+				}
+			}
+		}
+		for _, n := range nearest {
+			weights[[2]int{i, n.index}] = math.Exp(-math.Max(0, n.distance-rho) / sigma)
+		}
+	}
+
+	var edges []edge
+	for key, a := range weights {
+		if key[0] > key[1] {
+			if _, ok := weights[[2]int{key[1], key[0]}]; ok {
+				// the pair is combined when visited the other way round
+				continue
+			}
+		}
+		b := weights[[2]int{key[1], key[0]}]
+		edges = append(edges, edge{key[0], key[1], a + b - a*b})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		return edges[i].to < edges[j].to
+	})
+	return edges
+}
+
+// the curve parameters UMAP fits for a minimum distance of 0.1
+const (
+	umapA = 1.577
+	umapB = 0.895
+	// negative samples drawn for each positive edge sampled
+	umapNegativeSamples = 5
+)
+
+// lays out the graph by stochastic gradient descent: the chunks at either
+// end of an edge are pulled together, each edge sampled in proportion to its
+// weight, and random pairs are pushed apart. The learning rate decays
+// linearly over the epochs
+func optimizeLayout(layout [][]float64, edges []edge, epochs int, random *rand.Rand) {
+	var heaviest float64
+	for _, e := range edges {
+		heaviest = math.Max(heaviest, e.weight)
+	}
+	clip := func(g float64) float64 {
+		return math.Max(-4, math.Min(4, g))
+	}
+
+	for epoch := 0; epoch < epochs; epoch++ {
+		alpha := 1 - float64(epoch)/float64(epochs)
+		for _, e := range edges {
+			if random.Float64() > e.weight/heaviest {
+				continue
+			}
+			from, to := layout[e.from], layout[e.to]
+			dx, dy := from[0]-to[0], from[1]-to[1]
+			d2 := dx*dx + dy*dy
+			if d2 > 0 {
+				coefficient := -2 * umapA * umapB * math.Pow(d2, umapB-1) / (1 + umapA*math.Pow(d2, umapB))
+				gx, gy := clip(coefficient*dx), clip(coefficient*dy)
+				from[0] += alpha * gx
+				from[1] += alpha * gy
+				to[0] -= alpha * gx
+				to[1] -= alpha * gy
+			}
+
+			for s := 0; s < umapNegativeSamples; s++ {
+				other := random.Intn(len(layout))
+				if other == e.from {
+					continue
+				}
+				to = layout[other]
+				dx, dy = from[0]-to[0], from[1]-to[1]
+				d2 = dx*dx + dy*dy
+				coefficient := 2 * umapB / ((0.001 + d2) * (1 + umapA*math.Pow(d2, umapB)))
+				from[0] += alpha * clip(coefficient*dx)
+				from[1] += alpha * clip(coefficient*dy)
+			}
+		}
+	}
+}
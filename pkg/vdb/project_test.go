@@ -0,0 +1,86 @@
+package vdb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProjectPCAFindsMainDirection(t *testing.T) {
+	// points spread widely along (1, 1, 0) and a little along z
+	var chunks []Chunk
+	var along []float64
+	for i := 0; i < 10; i++ {
+		spread := float64(i) - 4.5
+		offset := 0.1 * float64(i%3-1)
+		chunks = append(chunks, NewChunk("a.pdf", string(rune('a'+i)), []float32{float32(spread), float32(spread), float32(offset)}))
+		along = append(along, spread)
+	}
+	points := ProjectPCA(chunks)
+	if len(points) != len(chunks) {
+		t.Fatalf("got %d points, want %d", len(points), len(chunks))
+	}
+
+	// the first component is the main direction, up to its sign, so x is
+	// the distance along it: spread * sqrt(2)
+	sign := math.Copysign(1, points[9].X)
+	var varianceX, varianceY float64
+	for i, p := range points {
+		if want := sign * along[i] * math.Sqrt2; math.Abs(p.X-want) > 1e-3 {
+			t.Errorf("point %d x = %v, want %v", i, p.X, want)
+		}
+		varianceX += p.X * p.X
+		varianceY += p.Y * p.Y
+	}
+	if varianceY >= varianceX {
+		t.Errorf("second component has variance %v, not less than the first's %v", varianceY, varianceX)
+	}
+	if points[3].ID != chunks[3].ID || points[3].Source != "a.pdf" {
+		t.Errorf("point 3 = %+v, want the labels of chunk 3", points[3])
+	}
+}
+
+func TestFuzzyGraph(t *testing.T) {
+	chunks := groupedChunks(5, 1)
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		vectors[i] = normalize(chunk.Embedding)
+	}
+	edges := fuzzyGraph(vectors, 4)
+
+	pairs := map[[2]int]bool{}
+	strongest := make([]float64, len(vectors))
+	for _, e := range edges {
+		if e.weight <= 0 || e.weight > 1+1e-9 {
+			t.Errorf("edge %d-%d has weight %v, want it in (0, 1]", e.from, e.to, e.weight)
+		}
+		pair := [2]int{min(e.from, e.to), max(e.from, e.to)}
+		if pairs[pair] {
+			t.Errorf("edge %d-%d appears more than once", e.from, e.to)
+		}
+		pairs[pair] = true
+		strongest[e.from] = math.Max(strongest[e.from], e.weight)
+		strongest[e.to] = math.Max(strongest[e.to], e.weight)
+	}
+	for i, w := range strongest {
+		// the nearest neighbor is always fully connected
+		if math.Abs(w-1) > 1e-9 {
+			t.Errorf("point %d has strongest edge %v, want 1", i, w)
+		}
+	}
+}
+
+func TestProjectUMAPKeepsGroupsTogether(t *testing.T) {
+	chunks := groupedChunks(8, 1)
+	points := ProjectUMAP(chunks, UMAPOptions{Neighbors: 5, Epochs: 200, Seed: 1})
+	for i, p := range points {
+		nearest, best := -1, math.Inf(1)
+		for j, q := range points {
+			if d := math.Hypot(p.X-q.X, p.Y-q.Y); i != j && d < best {
+				nearest, best = j, d
+			}
+		}
+		if points[nearest].Source != p.Source {
+			t.Errorf("point %d of %s is nearest to a point of %s", i, p.Source, points[nearest].Source)
+		}
+	}
+}
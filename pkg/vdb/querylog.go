@@ -0,0 +1,158 @@
+package vdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryRecord is an entry in the query log, written for each question a
+// Client answers.
+type QueryRecord struct {
+	Time     time.Time `json:"time"`
+	Question string    `json:"question"`
+	Sources  []string  `json:"sources,omitempty"`
+	// Chunks is the number of chunks retrieved as context.
+	Chunks int `json:"chunks"`
+	// TopScore is the similarity of the best retrieved chunk, or 0 if none
+	// was retrieved.
+	TopScore float32 `json:"top_score"`
+	// Retrieval and Generation are how long each stage took, in
+	// milliseconds.
+	Retrieval  int64 `json:"retrieval_ms"`
+	Generation int64 `json:"generation_ms"`
+	// Cached is set if the answer came from the answer cache.
+	Cached bool   `json:"cached,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// a query log writing JSON lines, shared by concurrent questions
+type queryLog struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func (l *queryLog) write(record QueryRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.encoder.Encode(record)
+}
+
+// ReadQueryLog reads the records of a query log written by a Client
+// created with WithQueryLog.
+func ReadQueryLog(r io.Reader) ([]QueryRecord, error) {
+	var records []QueryRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record QueryRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// QuestionCount is a question and the number of times it was asked.
+type QuestionCount struct {
+	Question string `json:"question"`
+	Count    int    `json:"count"`
+}
+
+// Latencies holds the average time taken by each stage of answering, in
+// milliseconds.
+type Latencies struct {
+	Retrieval  float64 `json:"retrieval_ms"`
+	Generation float64 `json:"generation_ms"`
+	Total      float64 `json:"total_ms"`
+}
+
+// QueryReport summarizes a query log.
+type QueryReport struct {
+	Queries int `json:"queries"`
+	Errors  int `json:"errors"`
+	Cached  int `json:"cached"`
+	// Frequent are the most often asked questions, most frequent first.
+	// Questions are compared ignoring case and spacing.
+	Frequent []QuestionCount `json:"frequent"`
+	// LowScore are the answered queries whose best chunk scored below the
+	// threshold, lowest first. These are likely gaps in the documents.
+	LowScore []QueryRecord `json:"low_score"`
+	// Latencies are averaged over the answers that were not cached.
+	Latencies Latencies `json:"latencies"`
+}
+
+// AnalyzeQueries summarizes the query log records, listing at most top
+// frequent questions and low scoring queries, where a query scores low if
+// its best chunk's similarity is below threshold.
+func AnalyzeQueries(records []QueryRecord, top int, threshold float32) QueryReport {
+	top = max(top, 0)
+	report := QueryReport{
+		Queries:  len(records),
+		Frequent: []QuestionCount{},
+		LowScore: []QueryRecord{},
+	}
+	counts := map[string]*QuestionCount{}
+	answered := 0
+	for _, record := range records {
+		key := normalizeQuestion(record.Question)
+		if counts[key] == nil {
+			counts[key] = &QuestionCount{Question: record.Question}
+		}
+		counts[key].Count++
+
+		if record.Error != "" {
+			report.Errors++
+			continue
+		}
+		if record.TopScore < threshold {
+			report.LowScore = append(report.LowScore, record)
+		}
+		if record.Cached {
+			report.Cached++
+			continue
+		}
+		answered++
+		report.Latencies.Retrieval += float64(record.Retrieval)
+		report.Latencies.Generation += float64(record.Generation)
+	}
+
+	for _, count := range counts {
+		report.Frequent = append(report.Frequent, *count)
+	}
+	sort.Slice(report.Frequent, func(i, j int) bool {
+		if report.Frequent[i].Count != report.Frequent[j].Count {
+			return report.Frequent[i].Count > report.Frequent[j].Count
+		}
+		return report.Frequent[i].Question < report.Frequent[j].Question
+	})
+	report.Frequent = report.Frequent[:min(top, len(report.Frequent))]
+
+	sort.SliceStable(report.LowScore, func(i, j int) bool {
+		return report.LowScore[i].TopScore < report.LowScore[j].TopScore
+	})
+	report.LowScore = report.LowScore[:min(top, len(report.LowScore))]
+
+	if answered > 0 {
+		report.Latencies.Retrieval /= float64(answered)
+		report.Latencies.Generation /= float64(answered)
+		report.Latencies.Total = report.Latencies.Retrieval + report.Latencies.Generation
+	}
+	return report
+}
+
+// lowercases the question and collapses its spacing, so the same question
+// asked slightly differently is counted once
+func normalizeQuestion(question string) string {
+	return strings.Join(strings.Fields(strings.ToLower(question)), " ")
+}
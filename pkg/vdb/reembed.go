@@ -0,0 +1,145 @@
+package vdb
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// DefaultReembedBatchSize is the number of chunks Reembed embeds at a time.
+const DefaultReembedBatchSize = 32
+
+// the progress of an interrupted Reembed, saved next to the store
+type reembedCheckpoint struct {
+	Model      string
+	Embeddings map[string][]float32
+}
+
+// ReembedOptions configures Store.Reembed.
+type ReembedOptions struct {
+	// BatchSize is the number of chunks embedded at a time. If it is zero,
+	// DefaultReembedBatchSize is used.
+	BatchSize int
+	// Progress, if not nil, is called after each batch with the number of
+	// chunks embedded so far and the total.
+	Progress func(done, total int)
+}
+
+// Reembed embeds the content of every chunk again with the embedder, which
+// uses the named model, then replaces the store's embeddings and saves it.
+// Until the new embeddings are all made the store is unchanged, and its
+// file is replaced atomically at the end.
+//
+// Progress is saved to a checkpoint file next to the store after each
+// batch, so if Reembed is interrupted, calling it again with the same model
+// carries on where it stopped.
+func (s *Store) Reembed(ctx context.Context, embedder Embedder, model string, opts ReembedOptions) error {
+	if s.path == "" {
+		return errors.New("cannot re-embed a store that is only in memory")
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReembedBatchSize
+	}
+
+	checkpointPath := s.path + ".reembed"
+	checkpoint, err := loadCheckpoint(checkpointPath, model)
+	if err != nil {
+		return err
+	}
+
+	chunks := s.Chunks()
+	var pending []Chunk
+	for _, chunk := range chunks {
+		if _, ok := checkpoint.Embeddings[chunk.ID]; !ok {
+			pending = append(pending, chunk)
+		}
+	}
+	done := len(chunks) - len(pending)
+	if opts.Progress != nil {
+		opts.Progress(done, len(chunks))
+	}
+
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Content
+		}
+		embeddings, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+		}
+		if len(embeddings) != len(batch) {
+			return fmt.Errorf("%w: got %d embeddings for %d chunks", ErrEmbeddingFailed, len(embeddings), len(batch))
+		}
+		for i, chunk := range batch {
+			checkpoint.Embeddings[chunk.ID] = embeddings[i]
+		}
+		if err := writeGob(checkpointPath, checkpoint); err != nil {
+			return err
+		}
+		done += len(batch)
+		if opts.Progress != nil {
+			opts.Progress(done, len(chunks))
+		}
+	}
+
+	for i := range chunks {
+		chunks[i].Embedding = checkpoint.Embeddings[chunks[i].ID]
+		if len(chunks[i].Embedding) != len(chunks[0].Embedding) {
+			return &DimensionError{Want: len(chunks[0].Embedding), Got: len(chunks[i].Embedding)}
+		}
+	}
+	if err := s.replace(chunks, model); err != nil {
+		return err
+	}
+	return os.Remove(checkpointPath)
+}
+
+// loads the checkpoint of an earlier Reembed with the same model, or
+// returns an empty one
+func loadCheckpoint(path string, model string) (*reembedCheckpoint, error) {
+	checkpoint := &reembedCheckpoint{Model: model, Embeddings: map[string][]float32{}}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var saved reembedCheckpoint
+	if err := gob.NewDecoder(file).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	if saved.Model != model {
+		// an interrupted re-embedding with another model is abandoned
+		return checkpoint, nil
+	}
+	if saved.Embeddings == nil {
+		saved.Embeddings = map[string][]float32{}
+	}
+	return &saved, nil
+}
+
+// replaces all the chunks and the embedding model, then saves the store.
+// If saving fails the store is left as it was
+func (s *Store) replace(chunks []Chunk, model string) error {
+	s.mu.Lock()
+	oldChunks, oldModel := s.chunks, s.embeddingModel
+	s.chunks, s.embeddingModel = chunks, model
+	s.mu.Unlock()
+
+	if err := s.Save(); err != nil {
+		s.mu.Lock()
+		s.chunks, s.embeddingModel = oldChunks, oldModel
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package vdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReembedResumesFromCheckpoint(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "vdb.gob"))
+	store.SetEmbeddingModel("old-model")
+	err := store.Add(
+		NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0}),
+		NewChunk("handbook.pdf", "sick leave needs a certificate", []float32{1, 0}),
+		NewChunk("policy.pdf", "expenses are approved by managers", []float32{0, 1}),
+		NewChunk("policy.pdf", "travel is booked through the portal", []float32{0, 1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var embedded []string
+	calls := 0
+	embedder := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("connection refused")
+		}
+		embedded = append(embedded, texts...)
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float32{float32(len(text)), 1, 1}
+		}
+		return embeddings, nil
+	})
+	opts := ReembedOptions{BatchSize: 2}
+
+	// the second batch fails, leaving the first in the checkpoint
+	if err := store.Reembed(context.Background(), embedder, "new-model", opts); !errors.Is(err, ErrEmbeddingFailed) {
+		t.Fatalf("first run: %v, want ErrEmbeddingFailed", err)
+	}
+	checkpoint := store.Path() + ".reembed"
+	if _, err := os.Stat(checkpoint); err != nil {
+		t.Fatalf("no checkpoint after the failed run: %v", err)
+	}
+	if store.EmbeddingModel() != "old-model" || store.Dimension() != 2 {
+		t.Errorf("store changed by the failed run: model %q, dimension %d", store.EmbeddingModel(), store.Dimension())
+	}
+
+	if err := store.Reembed(context.Background(), embedder, "new-model", opts); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if len(embedded) != 4 {
+		t.Errorf("embedded %d texts over both runs, want each of the 4 chunks once", len(embedded))
+	}
+	if _, err := os.Stat(checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkpoint kept after re-embedding finished: %v", err)
+	}
+
+	saved := NewStore(store.Path())
+	if err := saved.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if saved.EmbeddingModel() != "new-model" {
+		t.Errorf("saved embedding model = %q, want new-model", saved.EmbeddingModel())
+	}
+	for _, chunk := range saved.Chunks() {
+		if len(chunk.Embedding) != 3 || chunk.Embedding[0] != float32(len(chunk.Content)) {
+			t.Errorf("chunk %q has embedding %v, want the new model's", chunk.Content, chunk.Embedding)
+		}
+	}
+}
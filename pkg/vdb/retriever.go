@@ -0,0 +1,42 @@
+package vdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Retriever finds the stored chunks most relevant to a question.
+type Retriever struct {
+	// Store is searched for chunks.
+	Store *Store
+	// Embedder embeds the question. It must be the embedder, or use the
+	// same model as the embedder, that the stored chunks were embedded with.
+	Embedder Embedder
+	// K is the number of chunks to retrieve.
+	K int
+	// Sources restricts retrieval to chunks of these documents. If it is
+	// empty, all chunks are considered.
+	Sources []string
+	// Timeout limits how long a retrieval may take, if it is not zero.
+	Timeout time.Duration
+}
+
+// Retrieve embeds the question and returns the K chunks most similar to
+// it, highest score first.
+func (r *Retriever) Retrieve(ctx context.Context, question string) ([]ScoredChunk, error) {
+	ctx, cancel := withTimeout(ctx, r.Timeout)
+	defer cancel()
+	embedding, err := r.Embedder.Embed(ctx, []string{question})
+	if err != nil && !errors.Is(err, ErrEmbeddingFailed) {
+		err = fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("%w: no embedding for the question", ErrEmbeddingFailed)
+	}
+	return r.Store.Search(embedding[0], r.K, r.Sources)
+}
@@ -0,0 +1,29 @@
+package vdb
+
+import "math"
+
+// dot product of 2 float32 slices
+func dotproduct(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0.0
+	}
+	var dp float32
+	for i := 0; i < len(a); i++ {
+		dp += a[i] * b[i]
+	}
+	return dp
+}
+
+// magnitude of a float32 slice
+func magnitude(a []float32) float32 {
+	var mag float64
+	for i := 0; i < len(a); i++ {
+		mag += math.Pow(float64(a[i]), 2.0)
+	}
+	return float32(math.Sqrt(mag))
+}
+
+// Similarity returns the cosine similarity of 2 float32 slices.
+func Similarity(a, b []float32) float32 {
+	return dotproduct(a, b) / (magnitude(a) * magnitude(b))
+}
@@ -0,0 +1,254 @@
+package vdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot describes a named, saved state of a store. The chunks are kept
+// in segments, each holding a run of consecutive chunks of one document.
+// Segments are immutable and named by a hash of their contents, so
+// snapshots share the segments of the documents that did not change
+// between them and a snapshot costs little more than its manifest.
+type Snapshot struct {
+	Name           string            `json:"name"`
+	Created        time.Time         `json:"created"`
+	EmbeddingModel string            `json:"embedding_model,omitempty"`
+	Segments       []SnapshotSegment `json:"segments"`
+}
+
+// SnapshotSegment is a segment of a snapshot.
+type SnapshotSegment struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Chunks int    `json:"chunks"`
+}
+
+// Chunks returns the number of chunks in the snapshot.
+func (snap *Snapshot) Chunks() int {
+	n := 0
+	for _, segment := range snap.Segments {
+		n += segment.Chunks
+	}
+	return n
+}
+
+// the directory a store's snapshots are kept in, next to its file
+func (s *Store) snapshotDir() string {
+	return s.path + ".snapshots"
+}
+
+func (s *Store) manifestPath(name string) string {
+	return filepath.Join(s.snapshotDir(), name+".json")
+}
+
+func (s *Store) segmentPath(id string) string {
+	return filepath.Join(s.snapshotDir(), "segments", id+".gob")
+}
+
+func checkSnapshotName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+// CreateSnapshot saves the current chunks of the store as a snapshot with
+// the given name, which must not be in use. Only segments that no earlier
+// snapshot has are written.
+func (s *Store) CreateSnapshot(name string) (*Snapshot, error) {
+	if s.path == "" {
+		return nil, errors.New("cannot snapshot a store that is only in memory")
+	}
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(s.manifestPath(name)); err == nil {
+		return nil, fmt.Errorf("snapshot %q already exists", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.segmentPath("")), 0o755); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	chunks, model := append([]Chunk(nil), s.chunks...), s.embeddingModel
+	s.mu.RUnlock()
+
+	snap := &Snapshot{Name: name, Created: time.Now(), EmbeddingModel: model, Segments: []SnapshotSegment{}}
+	for start := 0; start < len(chunks); {
+		end := start + 1
+		for end < len(chunks) && chunks[end].Source == chunks[start].Source {
+			end++
+		}
+		segment, err := s.writeSegment(chunks[start:end])
+		if err != nil {
+			return nil, err
+		}
+		snap.Segments = append(snap.Segments, segment)
+		start = end
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return snap, writeAtomic(s.manifestPath(name), data)
+}
+
+// writes the chunks as a segment, unless a segment with the same contents
+// already exists
+func (s *Store) writeSegment(chunks []Chunk) (SnapshotSegment, error) {
+	segment := SnapshotSegment{ID: segmentID(chunks), Source: chunks[0].Source, Chunks: len(chunks)}
+	path := s.segmentPath(segment.ID)
+	if _, err := os.Stat(path); err == nil {
+		return segment, nil
+	}
+	return segment, writeGob(path, chunks)
+}
+
+// a hash of the chunks' contents. It is computed field by field rather than
+// from their gob encoding, which does not write map entries in a fixed order
+func segmentID(chunks []Chunk) string {
+	h := sha256.New()
+	for _, chunk := range chunks {
+		hashString(h, chunk.ID)
+		hashString(h, chunk.Source)
+		hashString(h, chunk.Content)
+		binary.Write(h, binary.LittleEndian, int64(len(chunk.Embedding)))
+		binary.Write(h, binary.LittleEndian, chunk.Embedding)
+		keys := make([]string, 0, len(chunk.Metadata))
+		for k := range chunk.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		binary.Write(h, binary.LittleEndian, int64(len(keys)))
+		for _, k := range keys {
+			hashString(h, k)
+			hashString(h, chunk.Metadata[k])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// writes s to the hash with its length, so that field boundaries count
+func hashString(h hash.Hash, s string) {
+	binary.Write(h, binary.LittleEndian, int64(len(s)))
+	h.Write([]byte(s))
+}
+
+// RestoreSnapshot replaces the chunks and embedding model of the store
+// with those of the named snapshot and saves the store. It returns
+// ErrSnapshotNotFound if there is no such snapshot. The snapshot is kept,
+// so it can be restored again.
+func (s *Store) RestoreSnapshot(name string) (*Snapshot, error) {
+	snap, err := s.readManifest(name)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]Chunk, 0, snap.Chunks())
+	for _, segment := range snap.Segments {
+		data, err := os.ReadFile(s.segmentPath(segment.ID))
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %q: cannot read segment: %w", name, err)
+		}
+		var segmentChunks []Chunk
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&segmentChunks); err != nil {
+			return nil, fmt.Errorf("snapshot %q: cannot decode segment %s: %w", name, segment.ID, err)
+		}
+		chunks = append(chunks, segmentChunks...)
+	}
+	return snap, s.replace(chunks, snap.EmbeddingModel)
+}
+
+func (s *Store) readManifest(name string) (*Snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.manifestPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrSnapshotNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("cannot parse snapshot %q: %w", name, err)
+	}
+	return &snap, nil
+}
+
+// Snapshots lists the snapshots of the store, oldest first.
+func (s *Store) Snapshots() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.snapshotDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		snap, err := s.readManifest(name)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, *snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// DeleteSnapshot removes the named snapshot, and the segments no other
+// snapshot uses.
+func (s *Store) DeleteSnapshot(name string) error {
+	if _, err := s.readManifest(name); err != nil {
+		return err
+	}
+	if err := os.Remove(s.manifestPath(name)); err != nil {
+		return err
+	}
+
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for _, snap := range snapshots {
+		for _, segment := range snap.Segments {
+			used[segment.ID] = true
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(s.segmentPath("")))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".gob")
+		if ok && !used[id] {
+			if err := os.Remove(s.segmentPath(id)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package vdb
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// a saved store with two documents
+func newSnapshotStore(t *testing.T) *Store {
+	t.Helper()
+	store := NewStore(filepath.Join(t.TempDir(), "vdb.gob"))
+	store.SetEmbeddingModel("nomic-embed-text")
+	err := store.Add(
+		NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0}),
+		NewChunk("policy.pdf", "expenses are approved by managers", []float32{0, 1}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// the IDs of the segment files kept for the store's snapshots
+func segmentFiles(t *testing.T, store *Store) []string {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Dir(store.segmentPath("")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, strings.TrimSuffix(entry.Name(), ".gob"))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func segmentIDs(snap *Snapshot) map[string]string {
+	ids := map[string]string{}
+	for _, segment := range snap.Segments {
+		ids[segment.Source] = segment.ID
+	}
+	return ids
+}
+
+// replaces the policy document so the second snapshot differs from the
+// first only in it
+func changePolicy(t *testing.T, store *Store) {
+	t.Helper()
+	store.RemoveDocument("policy.pdf")
+	if err := store.Add(NewChunk("policy.pdf", "expenses over $500 need a director", []float32{0, 1})); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotsShareUnchangedSegments(t *testing.T) {
+	store := newSnapshotStore(t)
+	first, err := store.CreateSnapshot("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changePolicy(t, store)
+	second, err := store.CreateSnapshot("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := segmentIDs(first), segmentIDs(second)
+	if a["handbook.pdf"] != b["handbook.pdf"] {
+		t.Errorf("unchanged handbook.pdf has segments %s and %s, want one shared", a["handbook.pdf"], b["handbook.pdf"])
+	}
+	if a["policy.pdf"] == b["policy.pdf"] {
+		t.Error("changed policy.pdf has the same segment in both snapshots")
+	}
+	if files := segmentFiles(t, store); len(files) != 3 {
+		t.Errorf("wrote segments %v, want 3", files)
+	}
+	if _, err := store.CreateSnapshot("first"); err == nil {
+		t.Error("created a second snapshot named first")
+	}
+}
+
+func TestRestoreSnapshotReplacesStoreFile(t *testing.T) {
+	store := newSnapshotStore(t)
+	if _, err := store.CreateSnapshot("before"); err != nil {
+		t.Fatal(err)
+	}
+	changePolicy(t, store)
+	store.SetEmbeddingModel("mxbai-embed-large")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.RestoreSnapshot("before"); err != nil {
+		t.Fatal(err)
+	}
+	saved := NewStore(store.Path())
+	if err := saved.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(documentNames(saved), ","), "handbook.pdf,policy.pdf"; got != want {
+		t.Errorf("saved documents = %s, want %s", got, want)
+	}
+	if chunks := saved.DocumentChunks("policy.pdf"); len(chunks) != 1 || chunks[0].Content != "expenses are approved by managers" {
+		t.Errorf("saved policy.pdf = %v, want the snapshot's version", chunks)
+	}
+	if saved.EmbeddingModel() != "nomic-embed-text" {
+		t.Errorf("saved embedding model = %q, want nomic-embed-text", saved.EmbeddingModel())
+	}
+
+	if _, err := store.RestoreSnapshot("missing"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("restoring a missing snapshot: %v, want ErrSnapshotNotFound", err)
+	}
+}
+
+func TestDeleteSnapshotRemovesOnlyUnusedSegments(t *testing.T) {
+	store := newSnapshotStore(t)
+	first, err := store.CreateSnapshot("first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changePolicy(t, store)
+	second, err := store.CreateSnapshot("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteSnapshot("first"); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, id := range segmentIDs(second) {
+		want = append(want, id)
+	}
+	sort.Strings(want)
+	if got := segmentFiles(t, store); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("kept segments %v, want the second snapshot's %v", got, want)
+	}
+	if _, err := os.Stat(store.segmentPath(segmentIDs(first)["policy.pdf"])); !errors.Is(err, os.ErrNotExist) {
+		t.Error("kept the segment only the deleted snapshot used")
+	}
+
+	snapshots, err := store.Snapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "second" {
+		t.Errorf("snapshots = %v, want only second", snapshots)
+	}
+	if _, err := store.RestoreSnapshot("second"); err != nil {
+		t.Errorf("cannot restore the remaining snapshot: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package vdb
+
+import "strings"
+
+// Split splits up the content into paragraph chunks and cleans them up
+// by removing duplicates and very short chunks.
+func Split(content string) []string {
+	split := strings.Split(content, "\n\n")
+	cleaned := []string{}
+	for _, s := range split {
+		cleaned = append(cleaned, strings.TrimSpace(s))
+	}
+	unique := removeDuplicates(cleaned)
+	shortRemoved := removeShortStrings(unique)
+	return shortRemoved
+}
+
+func removeDuplicates(s []string) []string {
+	m := make(map[string]bool)
+	result := []string{}
+	for _, item := range s {
+		if _, ok := m[item]; !ok {
+			m[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func removeShortStrings(slice []string) []string {
+	var result []string
+	for _, str := range slice {
+		sl := strings.Split(str, " ")
+		if len(sl) > 3 {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// WindowChunker splits text into chunks of Size words, each starting
+// Size-Overlap words after the previous one, so that consecutive chunks
+// share Overlap words.
+type WindowChunker struct {
+	Size    int
+	Overlap int
+}
+
+// Chunk splits the text into overlapping windows of words.
+func (w WindowChunker) Chunk(text string) []string {
+	words := strings.Fields(text)
+	step := w.Size - w.Overlap
+	if w.Size <= 0 || step <= 0 {
+		return nil
+	}
+	var chunks []string
+	for start := 0; start < len(words); start += step {
+		end := min(start+w.Size, len(words))
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,387 @@
+package vdb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Chunk is a piece of an ingested document together with its embedding.
+type Chunk struct {
+	ID        string
+	Embedding []float32
+	Content   string
+	Source    string
+	Metadata  map[string]string
+}
+
+// NewChunk returns a chunk of the given source document, with an ID derived
+// from the source and content.
+func NewChunk(source string, content string, embedding []float32) Chunk {
+	return Chunk{
+		ID:        chunkID(source, content),
+		Embedding: embedding,
+		Content:   content,
+		Source:    source,
+	}
+}
+
+// a stable ID for a chunk, derived from its source and content
+func chunkID(source string, content string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + content))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ScoredChunk is a stored chunk together with its similarity to a query.
+type ScoredChunk struct {
+	Score float32
+	Chunk
+}
+
+// DocumentInfo describes an ingested document.
+type DocumentInfo struct {
+	Name   string `json:"name"`
+	Chunks int    `json:"chunks"`
+}
+
+// Store holds chunks in memory and saves them to a gob file. It is safe for
+// concurrent use.
+type Store struct {
+	// Embedder embeds documents and queries for the langchaingo
+	// vectorstores.VectorStore methods. If it is nil, Ollama with the
+	// store's EmbeddingModel is used, or DefaultEmbeddingModel if the store
+	// does not record one.
+	Embedder Embedder
+
+	mu             sync.RWMutex
+	path           string
+	chunks         []Chunk
+	embeddingModel string
+}
+
+// NewStore returns an empty store that is saved to path. If path is empty,
+// the store is only kept in memory and Save does nothing.
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Open loads the store saved at path. If there is no file at path yet, an
+// empty store is returned and the file is created on the first Save.
+func Open(path string) (*Store, error) {
+	s := NewStore(path)
+	if err := s.Load(); err != nil && !errors.Is(err, ErrStoreNotFound) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path returns the path of the file the store is saved to.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// the contents of a store file
+type storeFile struct {
+	EmbeddingModel string
+	Chunks         []Chunk
+}
+
+// Load replaces the chunks in the store with those saved in its file. It
+// returns ErrStoreNotFound if the file does not exist.
+func (s *Store) Load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrStoreNotFound, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	var contents storeFile
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&contents); err != nil {
+		// stores written before the embedding model was recorded only
+		// have the chunks
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&contents.Chunks); err != nil {
+			return fmt.Errorf("cannot decode %s: %w", s.path, err)
+		}
+	}
+	// stores written before chunks had IDs
+	for i := range contents.Chunks {
+		if contents.Chunks[i].ID == "" {
+			contents.Chunks[i].ID = chunkID(contents.Chunks[i].Source, contents.Chunks[i].Content)
+		}
+	}
+
+	s.mu.Lock()
+	// stores written before Add replaced chunks with the same ID can have
+	// the same chunk more than once
+	s.chunks = nil
+	s.addUnique(contents.Chunks)
+	s.embeddingModel = contents.EmbeddingModel
+	s.mu.Unlock()
+	return nil
+}
+
+// Save writes the chunks in the store to its file. The file is replaced
+// atomically, so a failed save leaves the previous contents in place.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return writeGob(s.path, storeFile{
+		EmbeddingModel: s.embeddingModel,
+		Chunks:         s.chunks,
+	})
+}
+
+// gob encodes v into the file at path, replacing it atomically
+func writeGob(path string, v any) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return fmt.Errorf("cannot save %s: %w", path, err)
+	}
+	return writeAtomic(path, buf.Bytes())
+}
+
+// writes data to the file at path by writing a temporary file and renaming
+// it, so readers never see a partly written file
+func writeAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("cannot create %s: %w", path, err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("cannot save %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot save %s: %w", path, err)
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// EmbeddingModel returns the name of the embedding model the chunks in the
+// store were embedded with, or "" if it is not known.
+func (s *Store) EmbeddingModel() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.embeddingModel
+}
+
+// Version returns a string that changes whenever the chunks or the
+// embedding model of the store change, derived from their contents.
+func (s *Store) Version() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	h := sha256.New()
+	h.Write([]byte(s.embeddingModel))
+	for _, chunk := range s.chunks {
+		h.Write([]byte{0})
+		h.Write([]byte(chunk.ID))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// SetEmbeddingModel records the name of the embedding model the chunks in
+// the store were embedded with.
+func (s *Store) SetEmbeddingModel(model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.embeddingModel = model
+}
+
+// Add adds chunks to the store. A chunk with the same ID as one already in
+// the store replaces it where it is, so adding a document again does not
+// duplicate its chunks. They are not written to the file until Save is
+// called. If any chunk's embedding has a different number of dimensions
+// from the rest, none of the chunks are added and a *DimensionError is
+// returned.
+func (s *Store) Add(chunks ...Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	want := s.dimension()
+	for _, chunk := range chunks {
+		if want == 0 {
+			want = len(chunk.Embedding)
+		}
+		if len(chunk.Embedding) != want {
+			return &DimensionError{Want: want, Got: len(chunk.Embedding)}
+		}
+	}
+	s.addUnique(chunks)
+	return nil
+}
+
+// appends the chunks, replacing those with an ID already in the store.
+// The caller must hold the lock
+func (s *Store) addUnique(chunks []Chunk) {
+	index := make(map[string]int, len(s.chunks))
+	for i, chunk := range s.chunks {
+		index[chunk.ID] = i
+	}
+	for _, chunk := range chunks {
+		if i, ok := index[chunk.ID]; ok {
+			s.chunks[i] = chunk
+			continue
+		}
+		index[chunk.ID] = len(s.chunks)
+		s.chunks = append(s.chunks, chunk)
+	}
+}
+
+// Remove removes the chunks with the given IDs from the store, returning
+// the number removed. The file is not changed until Save is called.
+func (s *Store) Remove(ids ...string) int {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := make([]Chunk, 0, len(s.chunks))
+	for _, chunk := range s.chunks {
+		if !remove[chunk.ID] {
+			kept = append(kept, chunk)
+		}
+	}
+	removed := len(s.chunks) - len(kept)
+	s.chunks = kept
+	return removed
+}
+
+// RemoveDocument removes the chunks of the named document from the store,
+// returning the number removed. The file is not changed until Save is
+// called.
+func (s *Store) RemoveDocument(name string) int {
+	var ids []string
+	for _, chunk := range s.DocumentChunks(name) {
+		ids = append(ids, chunk.ID)
+	}
+	return s.Remove(ids...)
+}
+
+// Dimension returns the number of dimensions of the embeddings in the
+// store, or 0 if the store is empty.
+func (s *Store) Dimension() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dimension()
+}
+
+func (s *Store) dimension() int {
+	if len(s.chunks) == 0 {
+		return 0
+	}
+	return len(s.chunks[0].Embedding)
+}
+
+// Len returns the number of chunks in the store.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.chunks)
+}
+
+// Chunks returns a copy of the chunks in the store, in the order they were
+// added.
+func (s *Store) Chunks() []Chunk {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Chunk(nil), s.chunks...)
+}
+
+// DocumentChunks returns the chunks of the named document.
+func (s *Store) DocumentChunks(name string) []Chunk {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var chunks []Chunk
+	for _, chunk := range s.chunks {
+		if chunk.Source == name {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// Documents lists the documents in the store in the order they were added.
+func (s *Store) Documents() []DocumentInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := map[string]int{}
+	var documents []DocumentInfo
+	for _, chunk := range s.chunks {
+		i, ok := index[chunk.Source]
+		if !ok {
+			i = len(documents)
+			index[chunk.Source] = i
+			documents = append(documents, DocumentInfo{Name: chunk.Source})
+		}
+		documents[i].Chunks++
+	}
+	return documents
+}
+
+// HasDocument reports whether the store has chunks of the named document.
+func (s *Store) HasDocument(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, chunk := range s.chunks {
+		if chunk.Source == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the k chunks most similar to the embedding, highest score
+// first. If sources is not empty, only chunks of those documents are
+// considered. A k of zero or less returns no chunks. A *DimensionError is
+// returned if the embedding does not have the same number of dimensions as
+// the stored embeddings.
+func (s *Store) Search(embedding []float32, k int, sources []string) ([]ScoredChunk, error) {
+	allowed := map[string]bool{}
+	for _, source := range sources {
+		allowed[source] = true
+	}
+	return s.search(embedding, k, func(chunk Chunk) bool {
+		return len(allowed) == 0 || allowed[chunk.Source]
+	})
+}
+
+// returns the k chunks most similar to the embedding out of those that
+// keep returns true for, highest score first
+func (s *Store) search(embedding []float32, k int, keep func(Chunk) bool) ([]ScoredChunk, error) {
+	s.mu.RLock()
+	if want := s.dimension(); want != 0 && len(embedding) != want {
+		s.mu.RUnlock()
+		return nil, &DimensionError{Want: want, Got: len(embedding)}
+	}
+	scored := make([]ScoredChunk, 0, len(s.chunks))
+	for _, chunk := range s.chunks {
+		if !keep(chunk) {
+			continue
+		}
+		scored = append(scored, ScoredChunk{
+			Score: Similarity(embedding, chunk.Embedding),
+			Chunk: chunk,
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	return scored[:min(max(k, 0), len(scored))], nil
+}
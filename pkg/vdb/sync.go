@@ -0,0 +1,306 @@
+package vdb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// the chunk metadata keys a Syncer records the state of a document in
+const (
+	// the SHA-256 of the document file the chunks were made from
+	syncHashKey = "sync_sha256"
+	// the sync source the document was found in
+	syncSourceKey = "sync_source"
+)
+
+// Syncer keeps a store up to date with a set of sources, each a directory
+// or the URL of a document. Documents are ingested with a pipeline when they
+// are new or their content has changed, and their chunks are removed when
+// they are deleted from the source. Documents in a directory are named by
+// their path relative to it, using forward slashes, and a URL's document by
+// the last element of its path. Documents added to the store in other ways,
+// or synced from another source, are left alone, and a document with the
+// same name is skipped.
+type Syncer struct {
+	pipeline *Pipeline
+	sources  []string
+	// HTTPClient is used to download URL sources. If it is nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// NewSyncer returns a syncer that ingests documents from the sources with
+// the pipeline. Directories are recorded by their absolute path, so the
+// same directory is one source however it is written.
+func NewSyncer(pipeline *Pipeline, sources ...string) *Syncer {
+	cleaned := make([]string, len(sources))
+	for i, source := range sources {
+		cleaned[i] = cleanSource(source)
+	}
+	return &Syncer{pipeline: pipeline, sources: cleaned}
+}
+
+// returns the absolute path of a directory source, or a URL as it is
+func cleanSource(source string) string {
+	if isURL(source) {
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		return abs
+	}
+	return filepath.Clean(source)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// SyncResult lists the documents changed by a sync, by name.
+type SyncResult struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Removed   []string `json:"removed"`
+	// Skipped are the documents not synced because the store has a
+	// document with the same name from elsewhere.
+	Skipped []string `json:"skipped"`
+}
+
+// a document file found in a sync source. If err is set the file could not
+// be read; if its name ends in a slash it is a directory that could not be
+// read, standing for everything in it
+type syncFile struct {
+	name string
+	path string
+	hash string
+	err  error
+}
+
+// Sync checks every source once. The store is first reloaded from its file,
+// so changes other programs saved since the last Sync are kept. A document
+// that cannot be synced is skipped, and the errors for all of them are
+// returned joined together along with the result for the rest. The
+// documents of a source that cannot be read at all are not removed.
+func (s *Syncer) Sync(ctx context.Context) (SyncResult, error) {
+	result := SyncResult{Added: []string{}, Updated: []string{}, Removed: []string{}, Skipped: []string{}}
+	// pick up what other commands saved since the last sync, so saving the
+	// store does not overwrite it
+	if store := s.pipeline.store; store.Path() != "" {
+		if err := store.Load(); err != nil && !errors.Is(err, ErrStoreNotFound) {
+			return result, err
+		}
+	}
+	var errs []error
+	for _, source := range s.sources {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		files, cleanup, err := s.list(ctx, source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot read %s: %w", source, err))
+			continue
+		}
+
+		// files that cannot be read are kept as they are, not removed
+		present := map[string]bool{}
+		var unreadDirs []string
+		for _, file := range files {
+			present[file.name] = true
+			if file.err != nil {
+				errs = append(errs, fmt.Errorf("cannot read %s: %w", file.path, file.err))
+				if strings.HasSuffix(file.name, "/") {
+					unreadDirs = append(unreadDirs, file.name)
+				}
+				continue
+			}
+			if err := s.syncFile(ctx, source, file, &result); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for _, dir := range unreadDirs {
+			for _, info := range s.pipeline.store.Documents() {
+				if strings.HasPrefix(info.Name, dir) {
+					present[info.Name] = true
+				}
+			}
+		}
+		cleanup()
+		s.prune(source, present, &result)
+	}
+
+	// the pipeline saves what it ingests, but not the removal of an updated
+	// document's old chunks if the new version has none
+	if len(result.Updated) > 0 || len(result.Removed) > 0 {
+		if err := s.pipeline.store.Save(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// ingests the file unless the store already has the same content for it
+func (s *Syncer) syncFile(ctx context.Context, source string, file syncFile, result *SyncResult) error {
+	store := s.pipeline.store
+	old := store.DocumentChunks(file.name)
+	if len(old) > 0 && old[0].Metadata[syncSourceKey] != source {
+		result.Skipped = append(result.Skipped, file.name)
+		return nil
+	}
+	if len(old) > 0 && old[0].Metadata[syncHashKey] == file.hash {
+		result.Unchanged++
+		return nil
+	}
+
+	doc, err := s.pipeline.Convert(ctx, file.path)
+	if err != nil {
+		return fmt.Errorf("cannot convert %s: %w", file.name, err)
+	}
+	doc.Source = file.name
+	doc.Metadata = map[string]string{syncHashKey: file.hash, syncSourceKey: source}
+
+	store.RemoveDocument(file.name)
+	ids, err := s.pipeline.Ingest(ctx, doc)
+	if err != nil {
+		// keep the previous version rather than losing the document
+		store.RemoveDocument(file.name)
+		store.Add(old...)
+		return fmt.Errorf("cannot ingest %s: %w", file.name, err)
+	}
+	switch {
+	case len(old) > 0:
+		result.Updated = append(result.Updated, file.name)
+	case len(ids) > 0:
+		result.Added = append(result.Added, file.name)
+	}
+	return nil
+}
+
+// removes the documents synced from the source that are no longer in it
+func (s *Syncer) prune(source string, present map[string]bool, result *SyncResult) {
+	store := s.pipeline.store
+	for _, info := range store.Documents() {
+		chunks := store.DocumentChunks(info.Name)
+		if chunks[0].Metadata[syncSourceKey] != source || present[info.Name] {
+			continue
+		}
+		store.RemoveDocument(info.Name)
+		result.Removed = append(result.Removed, info.Name)
+	}
+}
+
+// lists the document files in the source. For a URL the document is
+// downloaded to a temporary file, which cleanup removes; a URL that is
+// gone lists no files, so its document is removed
+func (s *Syncer) list(ctx context.Context, source string) ([]syncFile, func(), error) {
+	if isURL(source) {
+		return s.download(ctx, source)
+	}
+
+	var files []syncFile
+	formats := map[string]bool{}
+	for _, ext := range Formats() {
+		formats[ext] = true
+	}
+	err := filepath.WalkDir(source, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil && p == source {
+			return err
+		}
+		rel, relErr := filepath.Rel(source, p)
+		if relErr != nil {
+			return relErr
+		}
+		name := filepath.ToSlash(rel)
+		if err != nil {
+			// skip what cannot be read and carry on with the rest
+			if entry != nil && entry.IsDir() {
+				files = append(files, syncFile{name: name + "/", path: p, err: err})
+				return fs.SkipDir
+			}
+			files = append(files, syncFile{name: name, path: p, err: err})
+			return nil
+		}
+		if entry.IsDir() || !formats[strings.ToLower(filepath.Ext(p))] {
+			return nil
+		}
+		hash, err := hashFile(p)
+		files = append(files, syncFile{name: name, path: p, hash: hash, err: err})
+		return nil
+	})
+	return files, func() {}, err
+}
+
+func (s *Syncer) download(ctx context.Context, source string) ([]syncFile, func(), error) {
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = u.Host
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, func() {}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	tempdir, err := os.MkdirTemp("", "vdb")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create a temporary directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tempdir) }
+	file, err := os.Create(filepath.Join(tempdir, name))
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return []syncFile{{name: name, path: file.Name(), hash: hex.EncodeToString(hash.Sum(nil))}}, cleanup, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
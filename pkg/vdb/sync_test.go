@@ -0,0 +1,196 @@
+package vdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// a syncer for the directory whose embedder counts the texts it embeds
+func newTestSyncer(t *testing.T, dir string) (*Syncer, *Store, *int) {
+	t.Helper()
+	RegisterConverter(".txt", ConverterFunc(func(ctx context.Context, filename string) (string, error) {
+		text, err := os.ReadFile(filename)
+		return string(text), err
+	}))
+	embedded := 0
+	embedder := EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		embedded += len(texts)
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float32{float32(len(text)), 1}
+		}
+		return embeddings, nil
+	})
+	store := NewStore(filepath.Join(t.TempDir(), "vdb.gob"))
+	return NewSyncer(NewPipeline(store, embedder), dir), store, &embedded
+}
+
+func writeFile(t *testing.T, path string, text string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func documentNames(store *Store) []string {
+	var names []string
+	for _, d := range store.Documents() {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSyncNamesDocumentsByRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a", "readme.txt"), "how to install the first tool")
+	writeFile(t, filepath.Join(dir, "b", "readme.txt"), "how to install the second tool")
+	syncer, store, embedded := newTestSyncer(t, dir)
+
+	for i := 0; i < 3; i++ {
+		result, err := syncer.Sync(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i > 0 && (len(result.Added) > 0 || len(result.Updated) > 0 || result.Unchanged != 2) {
+			t.Errorf("sync %d: %+v, want both documents unchanged", i+1, result)
+		}
+	}
+	if got, want := strings.Join(documentNames(store), ","), "a/readme.txt,b/readme.txt"; got != want {
+		t.Errorf("documents = %s, want %s", got, want)
+	}
+	if *embedded != 2 {
+		t.Errorf("embedded %d chunks over three syncs, want 2", *embedded)
+	}
+}
+
+func TestSyncLeavesOtherDocumentsAlone(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "handbook.txt"), "annual leave is 18 days a year")
+	syncer, store, _ := newTestSyncer(t, dir)
+	manual := NewChunk("handbook.txt", "added with vdb add", []float32{1, 1})
+	if err := store.Add(manual); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "handbook.txt" {
+		t.Errorf("skipped %v, want [handbook.txt]", result.Skipped)
+	}
+	chunks := store.DocumentChunks("handbook.txt")
+	if len(chunks) != 1 || chunks[0].ID != manual.ID {
+		t.Errorf("the added document was replaced by %v", chunks)
+	}
+}
+
+func TestSyncSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "handbook.txt"), "annual leave is 18 days a year")
+	if err := os.Symlink(filepath.Join(dir, "missing.txt"), filepath.Join(dir, "broken.txt")); err != nil {
+		t.Skip("cannot create symlinks:", err)
+	}
+	syncer, store, _ := newTestSyncer(t, dir)
+
+	result, err := syncer.Sync(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broken.txt") {
+		t.Errorf("error = %v, want one for broken.txt", err)
+	}
+	if len(result.Added) != 1 || !store.HasDocument("handbook.txt") {
+		t.Errorf("added %v, want handbook.txt to be synced despite broken.txt", result.Added)
+	}
+}
+
+func TestSyncRemovesDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "handbook.txt"), "annual leave is 18 days a year")
+	writeFile(t, filepath.Join(dir, "old", "policy.txt"), "expenses are approved by managers")
+	syncer, store, _ := newTestSyncer(t, dir)
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "old", "policy.txt")); err != nil {
+		t.Fatal(err)
+	}
+	result, err := syncer.Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "old/policy.txt" {
+		t.Errorf("removed %v, want [old/policy.txt]", result.Removed)
+	}
+	if got := strings.Join(documentNames(store), ","); got != "handbook.txt" {
+		t.Errorf("documents = %s, want handbook.txt", got)
+	}
+}
+
+func TestSyncKeepsChangesSavedBetweenSyncs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "handbook.txt"), "annual leave is 18 days a year")
+	syncer, store, _ := newTestSyncer(t, dir)
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// another command adds a document to the same file, as vdb add does
+	other := NewStore(store.Path())
+	if err := other.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Add(NewChunk("manual.pdf", "added with vdb add", []float32{1, 1})); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(dir, "policy.txt"), "expenses are approved by managers")
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	saved := NewStore(store.Path())
+	if err := saved.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(documentNames(saved), ","), "handbook.txt,manual.pdf,policy.txt"; got != want {
+		t.Errorf("saved documents = %s, want %s", got, want)
+	}
+}
+
+func TestSyncMatchesDirectoryHoweverWritten(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "handbook.txt"), "annual leave is 18 days a year")
+	syncer, store, _ := newTestSyncer(t, dir+string(filepath.Separator))
+	if _, err := syncer.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewSyncer(syncer.pipeline, rel).Sync(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Unchanged != 1 || len(result.Skipped) > 0 || len(result.Added) > 0 || len(result.Removed) > 0 {
+		t.Errorf("sync with %s: %+v, want handbook.txt unchanged", rel, result)
+	}
+	if got := strings.Join(documentNames(store), ","); got != "handbook.txt" {
+		t.Errorf("documents = %s, want handbook.txt", got)
+	}
+}
@@ -0,0 +1,205 @@
+package vdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/vectorstores"
+)
+
+// Store can be used anywhere langchaingo expects a vector store, for
+// example with vectorstores.ToRetriever.
+var _ vectorstores.VectorStore = (*Store)(nil)
+
+// AddDocuments embeds the documents and adds them to the store as chunks,
+// then saves the store, returning the IDs of the added chunks. The "source"
+// metadata value of a document becomes the source of its chunk and the
+// remaining metadata is kept with the chunk. Documents the
+// vectorstores.WithDeduplicater function reports as duplicates are skipped.
+func (s *Store) AddDocuments(ctx context.Context, docs []schema.Document, options ...vectorstores.Option) ([]string, error) {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	var texts []string
+	var kept []schema.Document
+	for _, doc := range docs {
+		if opts.Deduplicater != nil && opts.Deduplicater(ctx, doc) {
+			continue
+		}
+		texts = append(texts, doc.PageContent)
+		kept = append(kept, doc)
+	}
+	if len(kept) == 0 {
+		return []string{}, nil
+	}
+
+	embedder, err := s.embedder(opts)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	if len(vectors) != len(kept) {
+		return nil, fmt.Errorf("%w: got %d embeddings for %d documents", ErrEmbeddingFailed, len(vectors), len(kept))
+	}
+
+	chunks := make([]Chunk, 0, len(kept))
+	ids := make([]string, 0, len(kept))
+	for i, doc := range kept {
+		source, metadata := splitMetadata(doc.Metadata)
+		chunk := NewChunk(source, doc.PageContent, vectors[i])
+		chunk.Metadata = metadata
+		chunks = append(chunks, chunk)
+		ids = append(ids, chunk.ID)
+	}
+	if err := s.Add(chunks...); err != nil {
+		return nil, err
+	}
+	if err := s.Save(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// SimilaritySearch returns the numDocuments chunks most similar to the
+// query as documents, with the chunk's source, ID and metadata in the
+// document metadata. It supports the vectorstores.WithScoreThreshold and
+// vectorstores.WithEmbedder options, and vectorstores.WithFilters with a
+// map[string]any of metadata values chunks must have. A "source" filter
+// may be a string or a []string of acceptable sources. A name space set
+// with vectorstores.WithNameSpace restricts the search to that source.
+func (s *Store) SimilaritySearch(ctx context.Context, query string, numDocuments int, options ...vectorstores.Option) ([]schema.Document, error) {
+	opts := vectorstores.Options{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	keep, err := chunkFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, err := s.embedder(opts)
+	if err != nil {
+		return nil, err
+	}
+	embedding, err := embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbeddingFailed, err)
+	}
+	chunks, err := s.search(embedding, numDocuments, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []schema.Document
+	for _, chunk := range chunks {
+		if chunk.Score < opts.ScoreThreshold {
+			break
+		}
+		metadata := map[string]any{
+			"id":     chunk.ID,
+			"source": chunk.Source,
+		}
+		for k, v := range chunk.Metadata {
+			metadata[k] = v
+		}
+		docs = append(docs, schema.Document{
+			PageContent: chunk.Content,
+			Metadata:    metadata,
+			Score:       chunk.Score,
+		})
+	}
+	return docs, nil
+}
+
+// the langchaingo embedder given in the options, falling back to the
+// store's own embedder. Newlines are kept, so texts are embedded the same
+// way as by a Pipeline and a Retriever
+func (s *Store) embedder(opts vectorstores.Options) (embeddings.Embedder, error) {
+	if opts.Embedder != nil {
+		return opts.Embedder, nil
+	}
+	return embeddings.NewEmbedder(embeddings.EmbedderClientFunc(s.storeEmbedder().Embed), embeddings.WithStripNewLines(false))
+}
+
+// the store's Embedder, or Ollama with the store's embedding model, or
+// DefaultEmbeddingModel if the store does not record one
+func (s *Store) storeEmbedder() Embedder {
+	if s.Embedder != nil {
+		return s.Embedder
+	}
+	model := s.EmbeddingModel()
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+	return NewOllamaEmbedder(model)
+}
+
+// separates the source from the rest of a document's metadata, which is
+// kept with the chunk as strings
+func splitMetadata(metadata map[string]any) (string, map[string]string) {
+	source := ""
+	var rest map[string]string
+	for k, v := range metadata {
+		if k == "source" {
+			source = fmt.Sprint(v)
+			continue
+		}
+		if rest == nil {
+			rest = map[string]string{}
+		}
+		rest[k] = fmt.Sprint(v)
+	}
+	return source, rest
+}
+
+// turns the name space and filters options into a function reporting
+// whether a chunk matches them
+func chunkFilter(opts vectorstores.Options) (func(Chunk) bool, error) {
+	sources := map[string]bool{}
+	if opts.NameSpace != "" {
+		sources[opts.NameSpace] = true
+	}
+	metadata := map[string]string{}
+
+	if opts.Filters != nil {
+		filters, ok := opts.Filters.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unsupported filters type %T, expecting map[string]any", opts.Filters)
+		}
+		for k, v := range filters {
+			if k != "source" {
+				metadata[k] = fmt.Sprint(v)
+				continue
+			}
+			switch v := v.(type) {
+			case string:
+				sources[v] = true
+			case []string:
+				for _, source := range v {
+					sources[source] = true
+				}
+			default:
+				return nil, fmt.Errorf("unsupported source filter type %T", v)
+			}
+		}
+	}
+
+	return func(chunk Chunk) bool {
+		if len(sources) > 0 && !sources[chunk.Source] {
+			return false
+		}
+		for k, v := range metadata {
+			if chunk.Metadata[k] != v {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
@@ -0,0 +1,92 @@
+package vdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tmc/langchaingo/schema"
+)
+
+func TestStoreEmbedderUsesRecordedModel(t *testing.T) {
+	tests := []struct {
+		recorded string
+		want     string
+	}{
+		{"", DefaultEmbeddingModel},
+		{"mxbai-embed-large", "mxbai-embed-large"},
+	}
+	for _, tt := range tests {
+		store := NewStore("")
+		store.SetEmbeddingModel(tt.recorded)
+		embedder, ok := store.storeEmbedder().(*OllamaEmbedder)
+		if !ok {
+			t.Fatalf("recorded %q: embedder is %T, want *OllamaEmbedder", tt.recorded, store.storeEmbedder())
+		}
+		if embedder.Model != tt.want {
+			t.Errorf("recorded %q: embedding with %q, want %q", tt.recorded, embedder.Model, tt.want)
+		}
+	}
+}
+
+func TestAddDocumentsChecksEmbeddingCount(t *testing.T) {
+	store := NewStore("")
+	store.Embedder = EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return [][]float32{{1, 0}}, nil
+	})
+	docs := []schema.Document{
+		{PageContent: "annual leave is 18 days", Metadata: map[string]any{"source": "handbook.pdf"}},
+		{PageContent: "expenses are approved by managers", Metadata: map[string]any{"source": "handbook.pdf"}},
+	}
+	_, err := store.AddDocuments(context.Background(), docs)
+	if !errors.Is(err, ErrEmbeddingFailed) {
+		t.Errorf("error = %v, want ErrEmbeddingFailed", err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("store has %d chunks, want none", store.Len())
+	}
+}
+
+func TestVectorStoreKeepsNewlines(t *testing.T) {
+	var embedded []string
+	store := NewStore("")
+	store.Embedder = EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		embedded = append(embedded, texts...)
+		embeddings := make([][]float32, len(texts))
+		for i := range texts {
+			embeddings[i] = []float32{1, 0}
+		}
+		return embeddings, nil
+	})
+	doc := schema.Document{PageContent: "annual leave\nis 18 days", Metadata: map[string]any{"source": "handbook.pdf"}}
+	if _, err := store.AddDocuments(context.Background(), []schema.Document{doc}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.SimilaritySearch(context.Background(), "how much\nleave?", 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"annual leave\nis 18 days", "how much\nleave?"}
+	if len(embedded) != len(want) || embedded[0] != want[0] || embedded[1] != want[1] {
+		t.Errorf("embedded %q, want %q", embedded, want)
+	}
+}
+
+func TestSearchWithNegativeKReturnsNothing(t *testing.T) {
+	store := NewStore("")
+	store.Embedder = EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		return [][]float32{{1, 0}}, nil
+	})
+	if err := store.Add(NewChunk("handbook.pdf", "annual leave is 18 days", []float32{1, 0})); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []int{-1, 0} {
+		chunks, err := store.Search([]float32{1, 0}, k, nil)
+		if err != nil || len(chunks) != 0 {
+			t.Errorf("Search with k %d = %v, %v, want no chunks", k, chunks, err)
+		}
+		docs, err := store.SimilaritySearch(context.Background(), "how much leave?", k)
+		if err != nil || len(docs) != 0 {
+			t.Errorf("SimilaritySearch with k %d = %v, %v, want no documents", k, docs, err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"plugin"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// opens the Go plugin at path and returns its Convert function
+func loadPluginConverter(path string) (vdb.Converter, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup("Convert")
+	if err != nil {
+		return nil, err
+	}
+	convert, ok := symbol.(func(context.Context, string) (string, error))
+	if !ok {
+		return nil, fmt.Errorf("%s: Convert has type %T, expecting func(context.Context, string) (string, error)", path, symbol)
+	}
+	return vdb.ConverterFunc(convert), nil
+}
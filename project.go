@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+func projectCommand(args []string) {
+	projectCmd := newFlagSet("project")
+	method := projectCmd.String("method", "umap", "projection method: umap or pca")
+	output := projectCmd.String("o", "", "write the points to this JSON file instead of standard output")
+	neighbors := projectCmd.Int("neighbors", 15, "with umap, number of nearest neighbors to preserve")
+	epochs := projectCmd.Int("epochs", 200, "with umap, number of optimization passes")
+	seed := projectCmd.Int64("seed", 1, "with umap, random seed")
+	sourceList := projectCmd.String("sources", "", "comma-separated list of documents to project, all if empty")
+	projectCmd.Parse(args)
+
+	store := openClient(true).Store()
+	sources := parseSources(store, *sourceList)
+	if err := checkSources(store, sources); err != nil {
+		fail("cannot restrict sources", err)
+	}
+	chunks := store.Chunks()
+	if len(sources) > 0 {
+		chunks = nil
+		for _, source := range sources {
+			chunks = append(chunks, store.DocumentChunks(source)...)
+		}
+	}
+
+	var points []vdb.Point
+	switch *method {
+	case "pca":
+		points = vdb.ProjectPCA(chunks)
+	case "umap":
+		points = vdb.ProjectUMAP(chunks, vdb.UMAPOptions{Neighbors: *neighbors, Epochs: *epochs, Seed: *seed})
+	default:
+		fail("cannot project embeddings", fmt.Errorf("unknown method %q", *method))
+	}
+
+	if *output == "" {
+		printJSON(points)
+		return
+	}
+	data, err := json.MarshalIndent(points, "", "  ")
+	if err != nil {
+		fail("cannot encode points", err)
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fail("cannot write points", err)
+	}
+	if jsonOutput {
+		printJSON(map[string]any{"output": *output, "points": len(points), "method": *method})
+		return
+	}
+	log.Printf("wrote %d points to %s\n", len(points), *output)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// a snapshot, as reported in JSON output
+type snapshotReport struct {
+	Name           string `json:"name"`
+	Created        string `json:"created"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	Documents      int    `json:"documents"`
+	Chunks         int    `json:"chunks"`
+}
+
+func newSnapshotReport(snap *vdb.Snapshot) snapshotReport {
+	documents := map[string]bool{}
+	for _, segment := range snap.Segments {
+		documents[segment.Source] = true
+	}
+	return snapshotReport{
+		Name:           snap.Name,
+		Created:        snap.Created.Format(time.RFC3339),
+		EmbeddingModel: snap.EmbeddingModel,
+		Documents:      len(documents),
+		Chunks:         snap.Chunks(),
+	}
+}
+
+func snapshotCommand(args []string) {
+	snapshotCmd := newFlagSet("snapshot")
+	snapshotCmd.Parse(args)
+	action, name := snapshotCmd.Arg(0), snapshotCmd.Arg(1)
+	if action == "" || (action != "list" && name == "") {
+		fmt.Fprintln(os.Stderr, "usage: vdb snapshot create|restore|delete <name>, or vdb snapshot list")
+		os.Exit(2)
+	}
+
+	var snap *vdb.Snapshot
+	var err error
+	switch action {
+	case "create":
+		store := openClient(true).Store()
+		if snap, err = store.CreateSnapshot(name); err != nil {
+			fail("cannot create snapshot", err)
+		}
+		log.Printf("created snapshot %s of %d chunks\n", name, snap.Chunks())
+	case "restore":
+		store := openClient(false).Store()
+		if snap, err = store.RestoreSnapshot(name); err != nil {
+			fail("cannot restore snapshot", err)
+		}
+		log.Printf("restored snapshot %s of %d chunks\n", name, snap.Chunks())
+	case "delete":
+		if err := vdb.NewStore(storePath).DeleteSnapshot(name); err != nil {
+			fail("cannot delete snapshot", err)
+		}
+		log.Println("deleted snapshot", name)
+		if jsonOutput {
+			printJSON(map[string]string{"deleted": name})
+		}
+		return
+	case "list":
+		snapshotList()
+		return
+	default:
+		fmt.Fprintln(os.Stderr, "unknown snapshot action:", action)
+		os.Exit(2)
+	}
+	if jsonOutput {
+		printJSON(newSnapshotReport(snap))
+	}
+}
+
+func snapshotList() {
+	snapshots, err := vdb.NewStore(storePath).Snapshots()
+	if err != nil {
+		fail("cannot list snapshots", err)
+	}
+	reports := make([]snapshotReport, 0, len(snapshots))
+	for i := range snapshots {
+		reports = append(reports, newSnapshotReport(&snapshots[i]))
+	}
+	if jsonOutput {
+		printJSON(reports)
+		return
+	}
+	for _, r := range reports {
+		fmt.Printf("%-24s %s %4d documents %6d chunks\n", r.Name, r.Created, r.Documents, r.Chunks)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// a flag that can be given more than once, collecting the values
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func syncCommand(ctx context.Context, args []string) {
+	syncCmd := newFlagSet("sync")
+	every := syncCmd.Duration("every", 0, "keep running, syncing at this interval, instead of syncing once")
+	var sources listFlag
+	syncCmd.Var(&sources, "source", "a directory or document URL to sync, can be given more than once")
+	syncCmd.Parse(args)
+	if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: vdb sync [--every 1h] --source <dir or url> [--source ...]")
+		os.Exit(2)
+	}
+
+	loadConverters()
+	client := openClient(false)
+	syncer := vdb.NewSyncer(client.Pipeline(), sources...)
+	waitForOllama(ctx)
+	for {
+		result, err := syncer.Sync(ctx)
+		if ctx.Err() != nil {
+			fail("sync stopped", ctx.Err())
+		}
+		reportSync(result, err)
+		if *every <= 0 {
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+
+		log.Printf("next sync at %s\n", time.Now().Add(*every).Format(time.Kitchen))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*every):
+		}
+	}
+}
+
+// reports what a sync changed and the documents that could not be synced
+func reportSync(result vdb.SyncResult, err error) {
+	if jsonOutput {
+		report := map[string]any{"result": result}
+		if err != nil {
+			report["error"] = err.Error()
+			report["kind"], _ = describeError(err)
+		}
+		printJSON(report)
+		return
+	}
+	for _, name := range result.Added {
+		log.Println("added", name)
+	}
+	for _, name := range result.Updated {
+		log.Println("updated", name)
+	}
+	for _, name := range result.Removed {
+		log.Println("removed", name)
+	}
+	for _, name := range result.Skipped {
+		log.Println("skipped", name, "as the store has a document with the same name from elsewhere")
+	}
+	log.Printf("synced: %d added, %d updated, %d unchanged, %d removed, %d skipped\n",
+		len(result.Added), len(result.Updated), result.Unchanged, len(result.Removed), len(result.Skipped))
+	if err != nil {
+		log.Println(err)
+		if _, hint := describeError(err); hint != "" {
+			log.Println(hint)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+// the panes that can have keyboard focus, in tab order
+const (
+	focusDocs = iota
+	focusSearch
+	focusChat
+	numPanes
+)
+
+var (
+	paneStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240"))
+	focusedStyle = paneStyle.Copy().BorderForeground(lipgloss.Color("62"))
+	scoreStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("170")).Bold(true)
+	sourceStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	youStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+	statusStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// an ingested document shown in the document list. Selected documents
+// scope searches and questions to just those documents
+type docItem struct {
+	name     string
+	source   string
+	chunks   int
+	selected bool
+}
+
+func (d docItem) Title() string {
+	if d.selected {
+		return "[x] " + d.name
+	}
+	return "[ ] " + d.name
+}
+
+func (d docItem) Description() string { return fmt.Sprintf("%d chunks", d.chunks) }
+func (d docItem) FilterValue() string { return d.name }
+
+// results of a search started from the search box
+type searchResultMsg struct {
+	results []vdb.ScoredChunk
+	err     error
+}
+
+// a piece of a streamed answer
+type answerChunkMsg string
+
+// sent when an answer has finished streaming
+type answerDoneMsg struct {
+	err error
+}
+
+type tuiModel struct {
+	ctx     context.Context
+	client  *vdb.Client
+	focus   int
+	docs    list.Model
+	search  textinput.Model
+	results viewport.Model
+	chat    textinput.Model
+	answers viewport.Model
+
+	transcript string
+	stream     chan tea.Msg
+	cancel     context.CancelFunc
+	busy       bool
+	status     string
+	width      int
+	height     int
+}
+
+// runs the terminal UI until the user quits
+func runTUI(ctx context.Context, args []string) {
+	tuiCmd := newFlagSet("tui")
+	tuiCmd.Parse(args)
+
+	client := openClient(false)
+	waitForOllama(ctx)
+	// log output would corrupt the screen, errors are shown in the status line
+	log.SetOutput(io.Discard)
+
+	p := tea.NewProgram(newTUIModel(ctx, client), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "cannot run terminal UI:", err)
+		os.Exit(1)
+	}
+}
+
+func newTUIModel(ctx context.Context, client *vdb.Client) tuiModel {
+	docs := list.New(documentItems(client.Store()), list.NewDefaultDelegate(), 0, 0)
+	docs.Title = "Documents"
+	docs.SetShowHelp(false)
+	docs.KeyMap.Quit.SetEnabled(false)
+
+	search := textinput.New()
+	search.Placeholder = "search chunks and press enter"
+	search.Prompt = "search> "
+
+	chat := textinput.New()
+	chat.Placeholder = "ask a question and press enter"
+	chat.Prompt = "ask> "
+
+	return tuiModel{
+		ctx:     ctx,
+		client:  client,
+		docs:    docs,
+		search:  search,
+		results: newScrollView(),
+		chat:    chat,
+		answers: newScrollView(),
+		status:  fmt.Sprintf("loaded %d chunks | tab: switch pane | space: select document | enter: run | esc: stop answer | ctrl+c: quit", client.Store().Len()),
+	}
+}
+
+// returns a viewport scrolled only by the arrow and page keys, so that
+// typing into the input above it does not also scroll it
+func newScrollView() viewport.Model {
+	view := viewport.New(0, 0)
+	view.KeyMap = viewport.KeyMap{
+		PageDown: key.NewBinding(key.WithKeys("pgdown")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup")),
+		Down:     key.NewBinding(key.WithKeys("down")),
+		Up:       key.NewBinding(key.WithKeys("up")),
+	}
+	return view
+}
+
+// lists the documents in the store with the number of chunks stored for each
+func documentItems(store *vdb.Store) []list.Item {
+	documents := store.Documents()
+	items := make([]list.Item, 0, len(documents))
+	for _, d := range documents {
+		title := d.Name
+		if title == "" {
+			title = "(unknown source)"
+		}
+		items = append(items, docItem{name: title, source: d.Name, chunks: d.Chunks})
+	}
+	return items
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			if m.cancel != nil {
+				m.cancel()
+			}
+			return m, tea.Quit
+		case "esc":
+			if m.busy && m.cancel != nil {
+				m.cancel()
+				m.status = "stopping answer..."
+				return m, nil
+			}
+		case "tab":
+			m.setFocus((m.focus + 1) % numPanes)
+			return m, nil
+		case "shift+tab":
+			m.setFocus((m.focus + numPanes - 1) % numPanes)
+			return m, nil
+		case "enter":
+			if cmd, ok := m.submit(); ok {
+				return m, cmd
+			}
+		case " ":
+			if m.focus == focusDocs && m.docs.FilterState() != list.Filtering {
+				m.toggleSelected()
+				return m, nil
+			}
+		}
+
+	case searchResultMsg:
+		if msg.err != nil {
+			m.status = "search failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = fmt.Sprintf("found %d chunks", len(msg.results))
+		m.results.SetContent(m.renderChunks(msg.results))
+		m.results.GotoTop()
+		return m, nil
+
+	case answerChunkMsg:
+		m.transcript += string(msg)
+		m.refreshAnswers()
+		return m, waitForStream(m.stream)
+
+	case answerDoneMsg:
+		m.busy = false
+		m.cancel()
+		m.cancel = nil
+		m.transcript += "\n\n"
+		m.refreshAnswers()
+		m.status = "answer complete"
+		if msg.err != nil {
+			m.status = msg.err.Error()
+		}
+		return m, nil
+	}
+
+	// pass everything else on to the focused pane
+	var cmd tea.Cmd
+	switch m.focus {
+	case focusDocs:
+		m.docs, cmd = m.docs.Update(msg)
+	case focusSearch:
+		m.search, cmd = m.search.Update(msg)
+		var vpCmd tea.Cmd
+		m.results, vpCmd = m.results.Update(msg)
+		cmd = tea.Batch(cmd, vpCmd)
+	case focusChat:
+		m.chat, cmd = m.chat.Update(msg)
+		var vpCmd tea.Cmd
+		m.answers, vpCmd = m.answers.Update(msg)
+		cmd = tea.Batch(cmd, vpCmd)
+	}
+	return m, cmd
+}
+
+// handles enter in the focused pane, returning false if the pane
+// should handle the key itself
+func (m *tuiModel) submit() (tea.Cmd, bool) {
+	switch m.focus {
+	case focusDocs:
+		if m.docs.FilterState() == list.Filtering {
+			return nil, false
+		}
+		item, ok := m.docs.SelectedItem().(docItem)
+		if !ok {
+			return nil, true
+		}
+		m.status = "showing chunks of " + item.name
+		m.results.SetContent(m.renderChunks(documentChunks(m.client.Store(), item.source)))
+		m.results.GotoTop()
+		return nil, true
+
+	case focusSearch:
+		query := strings.TrimSpace(m.search.Value())
+		if query == "" {
+			return nil, true
+		}
+		m.status = "searching..."
+		retriever := m.client.Retriever(10, m.selectedSources()...)
+		ctx := m.ctx
+		return func() tea.Msg {
+			results, err := retriever.Retrieve(ctx, query)
+			return searchResultMsg{results: results, err: err}
+		}, true
+
+	case focusChat:
+		question := strings.TrimSpace(m.chat.Value())
+		if question == "" || m.busy {
+			return nil, true
+		}
+		m.busy = true
+		m.chat.SetValue("")
+		m.transcript += youStyle.Render("you: ") + question + "\n"
+		m.refreshAnswers()
+		m.status = "thinking..."
+		m.stream = make(chan tea.Msg)
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.cancel = cancel
+		go streamAnswer(ctx, m.client, question, m.selectedSources(), m.stream)
+		return waitForStream(m.stream), true
+	}
+	return nil, false
+}
+
+// answers the question from the given sources, sending the answer to ch
+// as it is generated
+func streamAnswer(ctx context.Context, client *vdb.Client, question string, sources []string, ch chan<- tea.Msg) {
+	_, err := client.Ask(ctx, question, sources, func(chunk string) {
+		ch <- answerChunkMsg(chunk)
+	})
+	ch <- answerDoneMsg{err: err}
+}
+
+// waits for the next message of a streamed answer
+func waitForStream(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// toggles whether the highlighted document is in the selection
+func (m *tuiModel) toggleSelected() {
+	item, ok := m.docs.SelectedItem().(docItem)
+	if !ok {
+		return
+	}
+	item.selected = !item.selected
+	m.docs.SetItem(m.docs.Index(), item)
+
+	sources := m.selectedSources()
+	if len(sources) == 0 {
+		m.status = "searching all documents"
+		return
+	}
+	m.status = fmt.Sprintf("searching %d selected documents", len(sources))
+}
+
+// the sources of the selected documents, none meaning all documents
+func (m tuiModel) selectedSources() []string {
+	var sources []string
+	for _, item := range m.docs.Items() {
+		if d, ok := item.(docItem); ok && d.selected {
+			sources = append(sources, d.source)
+		}
+	}
+	return sources
+}
+
+// the stored chunks of the given document, scored as 1
+func documentChunks(store *vdb.Store, source string) []vdb.ScoredChunk {
+	var chunks []vdb.ScoredChunk
+	for _, chunk := range store.DocumentChunks(source) {
+		chunks = append(chunks, vdb.ScoredChunk{Score: 1, Chunk: chunk})
+	}
+	return chunks
+}
+
+func (m *tuiModel) setFocus(focus int) {
+	m.focus = focus
+	m.search.Blur()
+	m.chat.Blur()
+	switch focus {
+	case focusSearch:
+		m.search.Focus()
+	case focusChat:
+		m.chat.Focus()
+	}
+}
+
+// lays the panes out to fill the terminal: the document list on the
+// left, the search pane top right and the chat pane bottom right
+func (m *tuiModel) resize() {
+	height := m.height - 1 // status line
+	leftWidth := m.width / 4
+	rightWidth := m.width - leftWidth
+	topHeight := height / 2
+	bottomHeight := height - topHeight
+
+	m.docs.SetSize(max(leftWidth-2, 0), max(height-2, 0))
+	m.search.Width = max(rightWidth-2-len(m.search.Prompt)-1, 0)
+	m.results.Width = max(rightWidth-2, 0)
+	m.results.Height = max(topHeight-3, 0)
+	m.chat.Width = max(rightWidth-2-len(m.chat.Prompt)-1, 0)
+	m.answers.Width = max(rightWidth-2, 0)
+	m.answers.Height = max(bottomHeight-3, 0)
+	m.refreshAnswers()
+}
+
+func (m *tuiModel) refreshAnswers() {
+	m.answers.SetContent(lipgloss.NewStyle().Width(m.answers.Width).Render(m.transcript))
+	m.answers.GotoBottom()
+}
+
+func (m tuiModel) renderChunks(chunks []vdb.ScoredChunk) string {
+	if len(chunks) == 0 {
+		return "no chunks found"
+	}
+	wrap := lipgloss.NewStyle().Width(m.results.Width)
+	var b strings.Builder
+	for _, chunk := range chunks {
+		b.WriteString(scoreStyle.Render(fmt.Sprintf("%.4f", chunk.Score)) + " " + sourceStyle.Render(chunk.Source) + "\n")
+		b.WriteString(wrap.Render(chunk.Content) + "\n\n")
+	}
+	return b.String()
+}
+
+func (m tuiModel) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	style := func(focus int) lipgloss.Style {
+		if m.focus == focus {
+			return focusedStyle
+		}
+		return paneStyle
+	}
+
+	left := style(focusDocs).Render(m.docs.View())
+	searchPane := style(focusSearch).Width(m.results.Width).Render(
+		lipgloss.JoinVertical(lipgloss.Left, m.search.View(), m.results.View()))
+	chatPane := style(focusChat).Width(m.answers.Width).Render(
+		lipgloss.JoinVertical(lipgloss.Left, m.answers.View(), m.chat.View()))
+	right := lipgloss.JoinVertical(lipgloss.Left, searchPane, chatPane)
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, left, right),
+		statusStyle.Render(m.status))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sausheong/vdb/pkg/vdb"
+)
+
+func TestTUIStreamsAnswerAcrossUpdates(t *testing.T) {
+	var model tea.Model = newTUIModel(context.Background(), vdb.NewClient(vdb.NewStore("")))
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	// an answer in progress, as started by submitting a question
+	m := model.(tuiModel)
+	m.busy = true
+	m.cancel = func() {}
+	model = m
+
+	for _, msg := range []tea.Msg{answerChunkMsg("The answer "), answerChunkMsg("is 42."), answerDoneMsg{}} {
+		model, _ = model.Update(msg)
+	}
+
+	m = model.(tuiModel)
+	if m.busy {
+		t.Error("still busy after the answer finished")
+	}
+	if !strings.Contains(m.transcript, "The answer is 42.") {
+		t.Errorf("transcript = %q, want it to contain the streamed answer", m.transcript)
+	}
+	if m.status != "answer complete" {
+		t.Errorf("status = %q, want %q", m.status, "answer complete")
+	}
+}
+
+func TestTUITypingDoesNotScrollResults(t *testing.T) {
+	var model tea.Model = newTUIModel(context.Background(), vdb.NewClient(vdb.NewStore("")))
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+
+	m := model.(tuiModel)
+	m.setFocus(focusSearch)
+	m.results.SetContent(strings.Repeat("line\n", 100))
+	model = m
+
+	for _, r := range "jump fbdu k" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m = model.(tuiModel)
+	if m.search.Value() != "jump fbdu k" {
+		t.Errorf("search = %q, want %q", m.search.Value(), "jump fbdu k")
+	}
+	if m.results.YOffset != 0 {
+		t.Errorf("results scrolled to %d while typing, want 0", m.results.YOffset)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	if m = model.(tuiModel); m.results.YOffset == 0 {
+		t.Error("pgdown did not scroll the results")
+	}
+}